@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend-golang/config"
+	"backend-golang/shiftcal"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Collector menulis satu baris per detik ke semua tabel sumber
+const expectedSamplePeriod = time.Second
+
+// Jeda minimal yang dilaporkan sebagai gap jika parameter gap_seconds kosong
+const defaultGapSeconds = 30
+
+// Batas coverage untuk indikator data_quality
+const (
+	coverageGood = 98.0
+	coverageFair = 90.0
+)
+
+// Tabel sumber data sensor beserta kolom waktunya
+type dataSource struct {
+	Name       string // nama line di kalender shift
+	Table      string
+	TimeColumn string
+}
+
+// Tentukan tabel dari parameter source: pasteur, separator, atau id line retail
+func resolveDataSource(source string) (dataSource, error) {
+	source = strings.ToLower(source)
+	switch source {
+	case "pasteur":
+		return dataSource{Name: "pasteur", Table: "readsensors_pasteurisasi1", TimeColumn: "Waktu"}, nil
+	case "separator":
+		return dataSource{Name: "separator", Table: "readsensors_separator", TimeColumn: "waktu"}, nil
+	}
+	if isRetailLine(source) {
+		return dataSource{Name: source, Table: getTableByLine(source), TimeColumn: "ts"}, nil
+	}
+	return dataSource{}, fmt.Errorf("source %s tidak dikenal. Gunakan pasteur, separator, atau id line retail", source)
+}
+
+// Jeda data lebih panjang dari batas gap
+type dataGap struct {
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds float64   `json:"duration_seconds"`
+}
+
+// Jumlah sampel yang seharusnya ada antara start dan min(end, now)
+func expectedSamples(start, end, now time.Time) int64 {
+	if now.Before(end) {
+		end = now
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return int64(end.Sub(start)/expectedSamplePeriod) + 1
+}
+
+// Indikator ringkas kelengkapan data untuk response KPI
+func dataQuality(expected, actual int64) gin.H {
+	if expected <= 0 {
+		return gin.H{"status": "no_data_expected", "coverage": 0.0, "expected_samples": expected, "actual_samples": actual}
+	}
+
+	coverage := math.Min(float64(actual)/float64(expected)*100, 100)
+	status := "poor"
+	switch {
+	case coverage >= coverageGood:
+		status = "good"
+	case coverage >= coverageFair:
+		status = "fair"
+	}
+	return gin.H{
+		"status":           status,
+		"coverage":         coverage,
+		"expected_samples": expected,
+		"actual_samples":   actual,
+	}
+}
+
+// Hitung jumlah baris dalam rentang waktu
+func countSamples(src dataSource, start, end time.Time) (int64, error) {
+	var count int64
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE `%s` >= ? AND `%s` <= ?", src.Table, src.TimeColumn, src.TimeColumn)
+	err := config.DB.Raw(query,
+		start.In(jakartaLoc).Format("2006-01-02 15:04:05"),
+		end.In(jakartaLoc).Format("2006-01-02 15:04:05"),
+	).Scan(&count).Error
+	return count, err
+}
+
+// Indikator data_quality satu line retail dalam satu shift (hanya query COUNT)
+func retailDataQuality(line string, start, end, now time.Time) gin.H {
+	if now.Before(start) {
+		return dataQuality(0, 0)
+	}
+	actual, err := countSamples(dataSource{Table: getTableByLine(line), TimeColumn: "ts"}, start, end)
+	if err != nil {
+		fmt.Printf("Error counting samples for %s: %v\n", line, err)
+		return gin.H{"status": "unknown", "error": err.Error()}
+	}
+	return dataQuality(expectedSamples(start, end, now), actual)
+}
+
+// Laporan kelengkapan lengkap: jumlah sampel, coverage dan daftar gap
+func checkCompleteness(src dataSource, start, end, now time.Time, gapSeconds float64) (gin.H, error) {
+	windowEnd := end
+	if now.Before(windowEnd) {
+		windowEnd = now
+	}
+
+	var stamps []time.Time
+	if windowEnd.After(start) {
+		query := fmt.Sprintf("SELECT `%s` FROM %s WHERE `%s` >= ? AND `%s` <= ? ORDER BY `%s` ASC",
+			src.TimeColumn, src.Table, src.TimeColumn, src.TimeColumn, src.TimeColumn)
+		rows, err := config.DB.Raw(query,
+			start.In(jakartaLoc).Format("2006-01-02 15:04:05"),
+			windowEnd.In(jakartaLoc).Format("2006-01-02 15:04:05"),
+		).Rows()
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var ts time.Time
+			if err := rows.Scan(&ts); err != nil {
+				return nil, err
+			}
+			stamps = append(stamps, toJakartaWall(ts))
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Gap dihitung juga di awal dan akhir window, jadi shift tanpa data = satu gap penuh
+	var gaps []dataGap
+	var gapTotal float64
+	prev := start.Add(-expectedSamplePeriod)
+	addGap := func(from, to time.Time) {
+		missing := to.Sub(from).Seconds() - expectedSamplePeriod.Seconds()
+		if missing <= gapSeconds {
+			return
+		}
+		gaps = append(gaps, dataGap{Start: from.Add(expectedSamplePeriod), End: to, DurationSeconds: missing})
+		gapTotal += missing
+	}
+	for _, ts := range stamps {
+		addGap(prev, ts)
+		prev = ts
+	}
+	if windowEnd.After(start) {
+		addGap(prev, windowEnd.Add(expectedSamplePeriod))
+	}
+
+	result := dataQuality(expectedSamples(start, end, now), int64(len(stamps)))
+	result["gap_threshold_seconds"] = gapSeconds
+	result["gap_count"] = len(gaps)
+	result["gap_total_seconds"] = gapTotal
+	result["gaps"] = gaps
+	return result, nil
+}
+
+// Controller untuk laporan kelengkapan data per shift
+func GetDataCompleteness(c *gin.Context) {
+	src, err := resolveDataSource(c.Query("source"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	gapSeconds := float64(defaultGapSeconds)
+	if v := c.Query("gap_seconds"); v != "" {
+		gapSeconds, err = strconv.ParseFloat(v, 64)
+		if err != nil || gapSeconds < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "gap_seconds harus angka tidak negatif"})
+			return
+		}
+	}
+
+	baseDate, err := parseRetailDate(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format tanggal salah. Gunakan YYYY-MM-DD"})
+		return
+	}
+
+	calendar, err := shiftcal.ForDate(src.Name, baseDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": fmt.Sprintf("Gagal mengambil kalender shift: %v", err)})
+		return
+	}
+	selected, err := filterShifts(calendar, c.Query("shift"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	now := time.Now().In(jakartaLoc)
+	shifts := []gin.H{}
+	for _, s := range selected {
+		report, err := checkCompleteness(src, s.Start, s.End, now, gapSeconds)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": fmt.Sprintf("Gagal mengambil data: %v", err)})
+			return
+		}
+		report["shift"] = s.No
+		report["start_time"] = s.Start
+		report["end_time"] = s.End
+		shifts = append(shifts, report)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"source":  src.Name,
+		"table":   src.Table,
+		"date":    baseDate.Format("2006-01-02"),
+		"shifts":  shifts,
+	})
+}
@@ -0,0 +1,404 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"backend-golang/config"
+	"backend-golang/models"
+	"backend-golang/shiftcal"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Body request untuk katalog alasan downtime. Category, Planned dan Active pointer
+// supaya update hanya mengubah field yang dikirim.
+type downtimeReasonInput struct {
+	Code     string  `json:"code"`
+	Name     string  `json:"name"`
+	Category *string `json:"category"`
+	Planned  *bool   `json:"planned"`
+	Active   *bool   `json:"active"`
+}
+
+// Body request untuk memberi alasan pada satu stop
+type stopReasonInput struct {
+	StopStart  string  `json:"stop_start"` // YYYY-MM-DD HH:MM:SS (WIB)
+	ReasonCode string  `json:"reason_code"`
+	Comment    *string `json:"comment"` // nil = komentar tidak diubah
+}
+
+// GetDowntimeReasons -> katalog alasan downtime
+func GetDowntimeReasons(c *gin.Context) {
+	var reasons []models.DowntimeReason
+
+	query := config.DB.Order("code ASC")
+	if c.Query("active") == "true" {
+		query = query.Where("active = ?", true)
+	}
+
+	if err := query.Find(&reasons).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil katalog alasan", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "count": len(reasons), "data": reasons})
+}
+
+// CreateDowntimeReason -> tambah alasan baru ke katalog
+func CreateDowntimeReason(c *gin.Context) {
+	var input downtimeReasonInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Body tidak valid", "error": err.Error()})
+		return
+	}
+
+	reason := models.DowntimeReason{
+		Code:    strings.ToUpper(strings.TrimSpace(input.Code)),
+		Name:    input.Name,
+		Planned: input.Planned != nil && *input.Planned,
+		Active:  input.Active == nil || *input.Active,
+	}
+	if input.Category != nil {
+		reason.Category = *input.Category
+	}
+	if reason.Code == "" || reason.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "code dan name wajib diisi"})
+		return
+	}
+
+	if err := config.DB.Create(&reason).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan alasan", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": reason})
+}
+
+// UpdateDowntimeReason -> ubah alasan di katalog (code tidak bisa diubah)
+func UpdateDowntimeReason(c *gin.Context) {
+	var reason models.DowntimeReason
+	if err := config.DB.First(&reason, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Alasan tidak ditemukan"})
+		return
+	}
+
+	var input downtimeReasonInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Body tidak valid", "error": err.Error()})
+		return
+	}
+
+	if input.Name != "" {
+		reason.Name = input.Name
+	}
+	if input.Category != nil {
+		reason.Category = *input.Category
+	}
+	if input.Planned != nil {
+		reason.Planned = *input.Planned
+	}
+	if input.Active != nil {
+		reason.Active = *input.Active
+	}
+
+	if err := config.DB.Save(&reason).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengubah alasan", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": reason})
+}
+
+// Cek kode alasan ada dan aktif
+func findActiveReason(code string) (models.DowntimeReason, error) {
+	var reason models.DowntimeReason
+	err := config.DB.Where("code = ? AND active = ?", strings.ToUpper(code), true).First(&reason).Error
+	return reason, err
+}
+
+// AssignStopReason -> beri alasan pada satu stop (diidentifikasi dari waktu mulai stop)
+func AssignStopReason(c *gin.Context) {
+	line := strings.ToLower(c.Param("line"))
+	if !isRetailLine(line) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": fmt.Sprintf("Line %s tidak terdaftar", line)})
+		return
+	}
+
+	var input stopReasonInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Body tidak valid", "error": err.Error()})
+		return
+	}
+
+	stopStart, err := time.ParseInLocation("2006-01-02 15:04:05", input.StopStart, jakartaLoc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "stop_start harus berformat YYYY-MM-DD HH:MM:SS"})
+		return
+	}
+
+	reason, err := findActiveReason(input.ReasonCode)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": fmt.Sprintf("Kode alasan %s tidak ditemukan atau tidak aktif", input.ReasonCode)})
+		return
+	}
+
+	// Cari stop yang dimaksud di shift tempat stop dimulai
+	shift, ok, err := shiftcal.At(line, stopStart)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": fmt.Sprintf("Gagal mengambil kalender shift: %v", err)})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "stop_start tidak berada di dalam shift manapun"})
+		return
+	}
+
+	events, err := getShiftStopEvents(line, shift, time.Now().In(jakartaLoc))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": fmt.Sprintf("Gagal mengambil data stop: %v", err)})
+		return
+	}
+
+	var stop *StopEvent
+	for i := range events {
+		if events[i].Start.Equal(stopStart) {
+			stop = &events[i]
+			break
+		}
+	}
+	if stop == nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": fmt.Sprintf("Stop dengan waktu mulai %s tidak ditemukan", input.StopStart)})
+		return
+	}
+	// Durasi stop yang masih berlangsung belum final, alasan diberikan setelah mesin jalan lagi
+	if stop.Open {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Stop masih berlangsung, alasan bisa diberikan setelah mesin jalan kembali"})
+		return
+	}
+
+	var existing int64
+	if err := config.DB.Model(&models.StopReason{}).Where("line = ? AND stop_start = ?", line, input.StopStart).Count(&existing).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengecek alasan stop", "error": err.Error()})
+		return
+	}
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Stop ini sudah diberi alasan, gunakan PUT untuk mengubah"})
+		return
+	}
+
+	record := models.StopReason{
+		Line:            line,
+		StopStart:       stop.Start.Format("2006-01-02 15:04:05"),
+		StopEnd:         stop.End.Format("2006-01-02 15:04:05"),
+		DurationSeconds: stop.DurationSeconds,
+		Date:            shift.Date,
+		Shift:           shift.No,
+		ReasonCode:      reason.Code,
+	}
+	if input.Comment != nil {
+		record.Comment = *input.Comment
+	}
+	if err := config.DB.Create(&record).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan alasan stop", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": record})
+}
+
+// UpdateStopReason -> ubah kode alasan / komentar stop
+func UpdateStopReason(c *gin.Context) {
+	line := strings.ToLower(c.Param("line"))
+
+	var record models.StopReason
+	if err := config.DB.Where("line = ?", line).First(&record, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Alasan stop tidak ditemukan"})
+		return
+	}
+
+	var input stopReasonInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Body tidak valid", "error": err.Error()})
+		return
+	}
+
+	if input.ReasonCode != "" {
+		reason, err := findActiveReason(input.ReasonCode)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": fmt.Sprintf("Kode alasan %s tidak ditemukan atau tidak aktif", input.ReasonCode)})
+			return
+		}
+		record.ReasonCode = reason.Code
+	}
+	if input.Comment != nil {
+		record.Comment = *input.Comment
+	}
+
+	if err := config.DB.Save(&record).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengubah alasan stop", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": record})
+}
+
+// Parse parameter from/to (YYYY-MM-DD), default hari ini
+func parseDateRange(c *gin.Context) (string, string, error) {
+	today := time.Now().In(jakartaLoc).Format("2006-01-02")
+	from := c.DefaultQuery("from", today)
+	to := c.DefaultQuery("to", from)
+
+	fromDate, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return "", "", fmt.Errorf("format from salah. Gunakan YYYY-MM-DD")
+	}
+	toDate, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return "", "", fmt.Errorf("format to salah. Gunakan YYYY-MM-DD")
+	}
+	if toDate.Before(fromDate) {
+		return "", "", fmt.Errorf("to tidak boleh sebelum from")
+	}
+	return from, to, nil
+}
+
+// GetStopReasons -> daftar alasan stop satu line dalam rentang tanggal
+func GetStopReasons(c *gin.Context) {
+	line := strings.ToLower(c.Param("line"))
+
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	query := config.DB.Where("line = ? AND date BETWEEN ? AND ?", line, from, to).Order("stop_start ASC")
+	if shift := c.Query("shift"); shift != "" {
+		query = query.Where("shift = ?", shift)
+	}
+
+	var records []models.StopReason
+	if err := query.Find(&records).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil alasan stop", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"line":    line,
+		"from":    from,
+		"to":      to,
+		"count":   len(records),
+		"data":    records,
+	})
+}
+
+// Ambil alasan stop per waktu mulai untuk melengkapi daftar stop
+func getStopReasonMap(line string, events []StopEvent) (map[string]models.StopReason, error) {
+	result := make(map[string]models.StopReason)
+	if len(events) == 0 {
+		return result, nil
+	}
+
+	starts := make([]string, 0, len(events))
+	for _, e := range events {
+		starts = append(starts, e.Start.Format("2006-01-02 15:04:05"))
+	}
+
+	var records []models.StopReason
+	if err := config.DB.Where("line = ? AND stop_start IN ?", strings.ToLower(line), starts).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		result[r.StopStart] = r
+	}
+	return result, nil
+}
+
+// GetDowntimePareto -> ranking alasan downtime berdasarkan total menit dan jumlah stop
+func GetDowntimePareto(c *gin.Context) {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	query := config.DB.Table("stop_reasons AS s").
+		Select("s.reason_code, r.name, r.category, r.planned, SUM(s.duration_seconds) / 60 AS total_minutes, COUNT(*) AS stop_count").
+		Joins("LEFT JOIN downtime_reasons r ON r.code = s.reason_code").
+		Where("s.date BETWEEN ? AND ?", from, to).
+		Group("s.reason_code, r.name, r.category, r.planned")
+
+	var lines []string
+	if lineParam := c.Query("lines"); lineParam != "" {
+		for _, l := range strings.Split(lineParam, ",") {
+			lines = append(lines, strings.ToLower(strings.TrimSpace(l)))
+		}
+		query = query.Where("s.line IN ?", lines)
+	}
+	if shift := c.Query("shift"); shift != "" {
+		query = query.Where("s.shift = ?", shift)
+	}
+
+	type paretoRow struct {
+		ReasonCode   string  `json:"reason_code"`
+		Name         string  `json:"name"`
+		Category     string  `json:"category"`
+		Planned      bool    `json:"planned"`
+		TotalMinutes float64 `json:"total_minutes"`
+		StopCount    int64   `json:"stop_count"`
+		Percentage   float64 `json:"percentage"`
+		Cumulative   float64 `json:"cumulative_percentage"`
+	}
+
+	var byMinutes, byCount []paretoRow
+	if err := query.Order("total_minutes DESC").Scan(&byMinutes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menghitung pareto", "error": err.Error()})
+		return
+	}
+
+	var totalMinutes float64
+	var totalCount int64
+	for _, r := range byMinutes {
+		totalMinutes += r.TotalMinutes
+		totalCount += r.StopCount
+	}
+
+	byCount = append(byCount, byMinutes...)
+	sort.SliceStable(byCount, func(i, j int) bool {
+		return byCount[i].StopCount > byCount[j].StopCount
+	})
+
+	cumulative := 0.0
+	for i := range byMinutes {
+		if totalMinutes > 0 {
+			byMinutes[i].Percentage = byMinutes[i].TotalMinutes / totalMinutes * 100
+		}
+		cumulative += byMinutes[i].Percentage
+		byMinutes[i].Cumulative = cumulative
+	}
+	cumulative = 0
+	for i := range byCount {
+		if totalCount > 0 {
+			byCount[i].Percentage = float64(byCount[i].StopCount) / float64(totalCount) * 100
+		}
+		cumulative += byCount[i].Percentage
+		byCount[i].Cumulative = cumulative
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"from":          from,
+		"to":            to,
+		"lines":         lines,
+		"shift":         c.Query("shift"),
+		"total_minutes": totalMinutes,
+		"total_stops":   totalCount,
+		"by_minutes":    byMinutes,
+		"by_count":      byCount,
+	})
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"backend-golang/config"
+)
+
+// Jeda maksimal antar sampel yang masih dianggap data berurutan.
+// Bisa diganti lewat env MAX_SAMPLE_GAP_SECONDS.
+const defaultMaxSampleGap = 10 * time.Second
+
+var (
+	maxSampleGapOnce  sync.Once
+	maxSampleGapValue time.Duration
+)
+
+// Baca batas jeda sampel sekali saja dari env
+func maxSampleGap() time.Duration {
+	maxSampleGapOnce.Do(func() {
+		maxSampleGapValue = defaultMaxSampleGap
+		if v := os.Getenv("MAX_SAMPLE_GAP_SECONDS"); v != "" {
+			seconds, err := strconv.ParseFloat(v, 64)
+			if err != nil || seconds <= 0 {
+				fmt.Printf("MAX_SAMPLE_GAP_SECONDS tidak valid (%q), pakai default %v\n", v, defaultMaxSampleGap)
+				return
+			}
+			maxSampleGapValue = time.Duration(seconds * float64(time.Second))
+		}
+	})
+	return maxSampleGapValue
+}
+
+// Lama waktu yang diwakili satu sampel: sampai sampel berikutnya (atau akhir window).
+// Jika jedanya melebihi maxGap (collector mati), sampel hanya dihitung satu periode
+// sampling supaya outage tidak ikut dijumlahkan.
+func sampleSpan(ts, next time.Time, maxGap time.Duration) time.Duration {
+	dt := next.Sub(ts)
+	if dt < 0 {
+		return 0
+	}
+	if dt > maxGap {
+		return expectedSamplePeriod
+	}
+	return dt
+}
+
+// Integrasikan durasi dari selisih timestamp antar sampel.
+// active(i) menentukan apakah sampel ke-i dihitung; sampel terakhir berlaku sampai windowEnd.
+func integrateDuration(times []time.Time, active func(i int) bool, windowEnd time.Time, maxGap time.Duration) time.Duration {
+	var total time.Duration
+	for i, ts := range times {
+		if !active(i) {
+			continue
+		}
+		next := windowEnd
+		if i+1 < len(times) {
+			next = times[i+1]
+		}
+		total += sampleSpan(ts, next, maxGap)
+	}
+	return total
+}
+
+// Durasi run dan stop satu line beserta jumlah sampel
+type runStopDuration struct {
+	Run     time.Duration
+	Stop    time.Duration
+	Samples int64
+}
+
+// Batas akhir integrasi: akhir shift, atau sekarang jika shift masih berjalan
+func integrationEnd(end, now time.Time) time.Time {
+	if now.Before(end) {
+		return now
+	}
+	return end
+}
+
+// Hasil agregasi run/stop per nilai start_mesin
+type runStopRow struct {
+	StartMesin int
+	Seconds    float64
+	Samples    int64
+}
+
+// Hitung durasi run (start_mesin = 1) dan stop (start_mesin = 0) dari timestamp.
+// Dihitung di database dalam satu query: jeda ke sampel berikutnya diambil dengan LEAD
+// (sampel terakhir sampai akhir window) lalu diperlakukan sama seperti sampleSpan.
+func getShiftRunStop(line string, start, end, now time.Time) (runStopDuration, error) {
+	if now.Before(start) {
+		return runStopDuration{}, nil
+	}
+	if !isRetailLine(line) {
+		return runStopDuration{}, fmt.Errorf("line %s tidak valid", line)
+	}
+
+	windowEnd := integrationEnd(end, now).In(jakartaLoc).Format("2006-01-02 15:04:05")
+	spans := retailQuery(line).
+		Select("start_mesin, TIMESTAMPDIFF(MICROSECOND, ts, COALESCE(LEAD(ts) OVER (ORDER BY ts), ?)) / 1000000 AS gap", windowEnd).
+		Where("ts >= ? AND ts <= ?", start.In(jakartaLoc).Format("2006-01-02 15:04:05"), end.In(jakartaLoc).Format("2006-01-02 15:04:05"))
+
+	var rows []runStopRow
+	err := config.DB.Table("(?) AS spans", spans).
+		Select("start_mesin, COUNT(*) AS samples, COALESCE(SUM(CASE WHEN gap < 0 THEN 0 WHEN gap > ? THEN ? ELSE gap END), 0) AS seconds",
+			maxSampleGap().Seconds(), expectedSamplePeriod.Seconds()).
+		Group("start_mesin").
+		Scan(&rows).Error
+	if err != nil {
+		return runStopDuration{}, err
+	}
+
+	var result runStopDuration
+	for _, r := range rows {
+		seconds := time.Duration(r.Seconds * float64(time.Second))
+		switch r.StartMesin {
+		case 1:
+			result.Run += seconds
+		case 0:
+			result.Stop += seconds
+		}
+		result.Samples += r.Samples
+	}
+	return result, nil
+}
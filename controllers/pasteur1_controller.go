@@ -3,6 +3,8 @@ package controllers
 import (
 	"encoding/json"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"backend-golang/config"
@@ -76,90 +78,106 @@ func GetPasteurDataPerHour(c *gin.Context) {
 	})
 }
 
-// GetPasteurAbnormal -> ambil periode suhu heating/holding di luar batas
+// GetPasteurAbnormal -> ambil periode parameter di luar batas alarm (tabel pasteur_alarm_limits)
 func GetPasteurAbnormal(c *gin.Context) {
 	tanggal := c.Query("tanggal") // YYYY-MM-DD
 	if tanggal == "" {
 		tanggal = time.Now().In(jakartaLoc).Format("2006-01-02")
 	}
-
-	// hasil query mentah
-	type rawPeriod struct {
-		Start      time.Time `json:"start"`
-		End        time.Time `json:"end"`
-		MinHeating float64   `json:"min_heating"`
-		MaxHeating float64   `json:"max_heating"`
-		MinHolding float64   `json:"min_holding"`
-		MaxHolding float64   `json:"max_holding"`
+	dayStart, err := time.ParseInLocation("2006-01-02", tanggal, jakartaLoc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Format tanggal salah. Gunakan YYYY-MM-DD",
+		})
+		return
 	}
 
-	// hasil akhir yang dikirim ke frontend
-	type AbnormalPeriod struct {
-		Start       string `json:"start"`
-		End         string `json:"end"`
-		SuhuHeating string `json:"suhu_heating,omitempty"`
-		SuhuHolding string `json:"suhu_holding,omitempty"`
+	// Opsional: batasi ke field tertentu, default semua field yang punya batas aktif
+	var fields []string
+	if param := c.Query("fields"); param != "" {
+		fields, err = parsePasteurFields(param)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+			return
+		}
 	}
 
-	var raws []rawPeriod
-	var result []AbnormalPeriod
-
-	query := `
-	WITH flagged AS (
-		SELECT Waktu, SuhuHeating, SuhuHolding
-		FROM readsensors_pasteurisasi1
-		WHERE DATE(Waktu) = ?
-	),
-	with_groups AS (
-		SELECT *,
-			ROW_NUMBER() OVER (ORDER BY Waktu) -
-			ROW_NUMBER() OVER (PARTITION BY (SuhuHeating < 105 OR SuhuHeating > 120) ORDER BY Waktu) AS grp_heat,
-			ROW_NUMBER() OVER (ORDER BY Waktu) -
-			ROW_NUMBER() OVER (PARTITION BY (SuhuHolding < 105 OR SuhuHolding > 120) ORDER BY Waktu) AS grp_hold
-		FROM flagged
-	)
-	SELECT 
-		MIN(Waktu) AS start,
-		MAX(Waktu) AS end,
-		MIN(SuhuHeating) AS min_heating,
-		MAX(SuhuHeating) AS max_heating,
-		MIN(SuhuHolding) AS min_holding,
-		MAX(SuhuHolding) AS max_holding
-	FROM with_groups
-	GROUP BY grp_heat, grp_hold
-	ORDER BY start;
-	`
+	// Opsional: hanya severity tertentu (warning / critical)
+	severity := strings.ToLower(c.Query("severity"))
+	if severity != "" && severity != severityWarning && severity != severityCritical {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "severity harus warning atau critical"})
+		return
+	}
 
-	if err := config.DB.Raw(query, tanggal).Scan(&raws).Error; err != nil {
+	limits, err := getActiveAlarmLimits(fields)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"message": "Gagal mengambil data abnormal",
+			"message": "Gagal mengambil batas alarm",
 			"error":   err.Error(),
 		})
 		return
 	}
 
-	// mapping ke hasil akhir
-	for _, r := range raws {
-		ab := AbnormalPeriod{
-			Start: r.Start.Format("2006-01-02 15:04:05"),
-			End:   r.End.Format("2006-01-02 15:04:05"),
+	result := []abnormalPeriod{}
+	if len(limits) > 0 {
+		limitFields := make([]string, 0, len(limits)+2)
+		for _, l := range limits {
+			limitFields = append(limitFields, l.Field)
+			// Setpoint VDLL/VDHH dipakai sebagai batas level_vd
+			if l.Field == "level_vd" {
+				limitFields = append(limitFields, "vdll", "vdhh")
+			}
 		}
 
-		if r.MaxHeating > 120 {
-			ab.SuhuHeating = ">120"
-		} else if r.MinHeating < 105 {
-			ab.SuhuHeating = "<105"
+		series, err := getPasteurSeries(limitFields, dayStart, dayStart.Add(24*time.Hour-time.Second))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Gagal mengambil data abnormal",
+				"error":   err.Error(),
+			})
+			return
 		}
 
-		if r.MaxHolding > 120 {
-			ab.SuhuHolding = ">120"
-		} else if r.MinHolding < 105 {
-			ab.SuhuHolding = "<105"
+		for _, l := range limits {
+			periods := detectAbnormalPeriods(l.Field, sampleLimits(l, series), series.Times, series.Values[l.Field], maxSampleGap())
+			for _, p := range periods {
+				if severity != "" && p.Severity != severity {
+					continue
+				}
+				switch p.Field {
+				case "suhu_heating":
+					p.SuhuHeating = p.Label
+				case "suhu_holding":
+					p.SuhuHolding = p.Label
+				}
+				result = append(result, p)
+			}
 		}
-
-		if ab.SuhuHeating != "" || ab.SuhuHolding != "" {
-			result = append(result, ab)
+		sort.SliceStable(result, func(i, j int) bool { return result[i].Start < result[j].Start })
+	}
+
+	// Ringkasan per severity dan per parameter
+	type fieldSummary struct {
+		Count           int     `json:"count"`
+		Critical        int     `json:"critical"`
+		DurationSeconds float64 `json:"duration_seconds"`
+	}
+	bySeverity := map[string]int{severityWarning: 0, severityCritical: 0}
+	byField := make(map[string]*fieldSummary)
+	for _, p := range result {
+		bySeverity[p.Severity]++
+		f, ok := byField[p.Field]
+		if !ok {
+			f = &fieldSummary{}
+			byField[p.Field] = f
+		}
+		f.Count++
+		f.DurationSeconds += p.DurationSeconds
+		if p.Severity == severityCritical {
+			f.Critical++
 		}
 	}
 
@@ -171,6 +189,8 @@ func GetPasteurAbnormal(c *gin.Context) {
 		"success": true,
 		"tanggal": tanggal,
 		"count":   len(result),
+		"summary": gin.H{"by_severity": bySeverity, "by_field": byField},
+		"limits":  limits,
 		"data":    result,
 	})
 }
@@ -212,146 +232,15 @@ func getTimeRange(c *gin.Context) (time.Time, time.Time, error) {
 
 // GetAverageFlowrate - Menampilkan rata-rata flowrate per menit
 func GetAverageFlowrate(c *gin.Context) {
-	var results []AvgResponse
-
-	// Dapatkan time range
-	startTime, endTime, err := getTimeRange(c)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid date format. Use: YYYY-MM-DD HH:MM:SS or YYYY-MM-DDTHH:MM:SS",
-			"details": err.Error(),
-		})
-		return
-	}
-
-	// Query untuk menghitung average per menit dengan filter tanggal
-	// Data di database sudah dalam timezone WIB, jadi tidak perlu CONVERT_TZ
-	// Format waktu ke string untuk memastikan MySQL menerima waktu yang tepat
-	startStr := startTime.Format("2006-01-02 15:04:05")
-	endStr := endTime.Format("2006-01-02 15:04:05")
-	
-	query := `
-		SELECT 
-			DATE_FORMAT(Waktu, '%Y-%m-%d %H:%i:00') as timestamp,
-			AVG(Flowrate) as average
-		FROM readsensors_pasteurisasi1
-		WHERE Waktu >= ? AND Waktu <= ?
-		GROUP BY DATE_FORMAT(Waktu, '%Y-%m-%d %H:%i:00')
-		ORDER BY timestamp ASC
-	`
-
-	if err := config.DB.Raw(query, startStr, endStr).Scan(&results).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch average flowrate data",
-			"details": err.Error(),
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"status": "success",
-		"data":   results,
-		"count":  len(results),
-		"filter": gin.H{
-			"start_date": startTime.Format("2006-01-02 15:04:05"),
-			"end_date":   endTime.Format("2006-01-02 15:04:05"),
-			"timezone":   "Asia/Jakarta (WIB)",
-		},
-	})
+	pasteurAverageHandler("flowrate", "flowrate")(c)
 }
 
 // GetAverageSuhuHeating - Menampilkan rata-rata suhu heating per menit
 func GetAverageSuhuHeating(c *gin.Context) {
-	var results []AvgResponse
-
-	// Dapatkan time range
-	startTime, endTime, err := getTimeRange(c)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid date format. Use: YYYY-MM-DD HH:MM:SS or YYYY-MM-DDTHH:MM:SS",
-			"details": err.Error(),
-		})
-		return
-	}
-
-	// Format waktu ke string untuk memastikan MySQL menerima waktu yang tepat
-	startStr := startTime.Format("2006-01-02 15:04:05")
-	endStr := endTime.Format("2006-01-02 15:04:05")
-	
-	query := `
-		SELECT 
-			DATE_FORMAT(Waktu, '%Y-%m-%d %H:%i:00') as timestamp,
-			AVG(SuhuHeating) as average
-		FROM readsensors_pasteurisasi1
-		WHERE Waktu >= ? AND Waktu <= ?
-		GROUP BY DATE_FORMAT(Waktu, '%Y-%m-%d %H:%i:00')
-		ORDER BY timestamp ASC
-	`
-
-	if err := config.DB.Raw(query, startStr, endStr).Scan(&results).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch average suhu heating data",
-			"details": err.Error(),
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"status": "success",
-		"data":   results,
-		"count":  len(results),
-		"filter": gin.H{
-			"start_date": startTime.Format("2006-01-02 15:04:05"),
-			"end_date":   endTime.Format("2006-01-02 15:04:05"),
-			"timezone":   "Asia/Jakarta (WIB)",
-		},
-	})
+	pasteurAverageHandler("suhu_heating", "suhu heating")(c)
 }
 
 // GetAverageSuhuHolding - Menampilkan rata-rata suhu holding per menit
 func GetAverageSuhuHolding(c *gin.Context) {
-	var results []AvgResponse
-
-	// Dapatkan time range
-	startTime, endTime, err := getTimeRange(c)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid date format. Use: YYYY-MM-DD HH:MM:SS or YYYY-MM-DDTHH:MM:SS",
-			"details": err.Error(),
-		})
-		return
-	}
-
-	// Format waktu ke string untuk memastikan MySQL menerima waktu yang tepat
-	startStr := startTime.Format("2006-01-02 15:04:05")
-	endStr := endTime.Format("2006-01-02 15:04:05")
-	
-	query := `
-		SELECT 
-			DATE_FORMAT(Waktu, '%Y-%m-%d %H:%i:00') as timestamp,
-			AVG(SuhuHolding) as average
-		FROM readsensors_pasteurisasi1
-		WHERE Waktu >= ? AND Waktu <= ?
-		GROUP BY DATE_FORMAT(Waktu, '%Y-%m-%d %H:%i:00')
-		ORDER BY timestamp ASC
-	`
-
-	if err := config.DB.Raw(query, startStr, endStr).Scan(&results).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch average suhu holding data",
-			"details": err.Error(),
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"status": "success",
-		"data":   results,
-		"count":  len(results),
-		"filter": gin.H{
-			"start_date": startTime.Format("2006-01-02 15:04:05"),
-			"end_date":   endTime.Format("2006-01-02 15:04:05"),
-			"timezone":   "Asia/Jakarta (WIB)",
-		},
-	})
-}
\ No newline at end of file
+	pasteurAverageHandler("suhu_holding", "suhu holding")(c)
+}
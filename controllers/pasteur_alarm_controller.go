@@ -0,0 +1,388 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"backend-golang/config"
+	"backend-golang/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Level alarm, urut dari paling ringan
+const (
+	alarmNormal = ""
+	alarmLow    = "L"
+	alarmHigh   = "H"
+	alarmLowLow = "LL"
+	alarmHiHi   = "HH"
+)
+
+// Tentukan level alarm satu nilai. Batas bersifat eksklusif (nilai = batas masih normal),
+// sama dengan perilaku lama < 105 dan > 120.
+func classifyAlarm(limit models.AlarmLimit, v float64) string {
+	switch {
+	case limit.LL != nil && v < *limit.LL:
+		return alarmLowLow
+	case limit.HH != nil && v > *limit.HH:
+		return alarmHiHi
+	case limit.L != nil && v < *limit.L:
+		return alarmLow
+	case limit.H != nil && v > *limit.H:
+		return alarmHigh
+	}
+	return alarmNormal
+}
+
+// LL/HH lebih parah dari L/H
+func alarmRank(level string) int {
+	switch level {
+	case alarmLow, alarmHigh:
+		return 1
+	case alarmLowLow, alarmHiHi:
+		return 2
+	}
+	return 0
+}
+
+func isLowAlarm(level string) bool {
+	return level == alarmLow || level == alarmLowLow
+}
+
+// Nilai batas yang dilanggar untuk satu level
+func alarmThreshold(limit models.AlarmLimit, level string) *float64 {
+	switch level {
+	case alarmLowLow:
+		return limit.LL
+	case alarmLow:
+		return limit.L
+	case alarmHigh:
+		return limit.H
+	case alarmHiHi:
+		return limit.HH
+	}
+	return nil
+}
+
+// Label batas yang dilanggar, misal ">120" atau "<105"
+func alarmLabel(limit models.AlarmLimit, level string) string {
+	threshold := alarmThreshold(limit, level)
+	if threshold == nil {
+		return ""
+	}
+	if isLowAlarm(level) {
+		return fmt.Sprintf("<%g", *threshold)
+	}
+	return fmt.Sprintf(">%g", *threshold)
+}
+
+// L/H = warning, LL/HH = critical
+func alarmSeverity(level string) string {
+	if alarmRank(level) >= 2 {
+		return severityCritical
+	}
+	return severityWarning
+}
+
+// Satu periode berurutan di luar batas untuk satu field
+type abnormalPeriod struct {
+	Field           string  `json:"field"`
+	Level           string  `json:"level"` // level terparah selama periode
+	Severity        string  `json:"severity"`
+	Label           string  `json:"label"`
+	Threshold       float64 `json:"threshold"`
+	Start           string  `json:"start"`
+	End             string  `json:"end"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Peak            float64 `json:"peak"` // nilai terjauh dari batas (min untuk low, max untuk high)
+	Min             float64 `json:"min"`
+	Max             float64 `json:"max"`
+
+	// Field lama GetPasteurAbnormal (">120" / "<105"), tetap diisi untuk halaman yang sudah ada
+	SuhuHeating string `json:"suhu_heating,omitempty"`
+	SuhuHolding string `json:"suhu_holding,omitempty"`
+}
+
+// Batas per sampel. level_vd memakai setpoint VDLL/VDHH dari PLC sebagai LL/HH
+// jika LL/HH tidak diisi di konfigurasi.
+func sampleLimits(limit models.AlarmLimit, series pasteurSeries) func(i int) models.AlarmLimit {
+	vdll, vdhh := series.Values["vdll"], series.Values["vdhh"]
+	if limit.Field != "level_vd" || vdll == nil || vdhh == nil {
+		return func(int) models.AlarmLimit { return limit }
+	}
+	return func(i int) models.AlarmLimit {
+		l := limit
+		if l.LL == nil && vdll[i] > 0 {
+			v := vdll[i]
+			l.LL = &v
+		}
+		if l.HH == nil && vdhh[i] > 0 {
+			v := vdhh[i]
+			l.HH = &v
+		}
+		return l
+	}
+}
+
+// Kelompokkan sampel di luar batas menjadi periode. Jeda data lebih dari maxGap memutus periode.
+func detectAbnormalPeriods(field string, limitAt func(i int) models.AlarmLimit, times []time.Time, values []float64, maxGap time.Duration) []abnormalPeriod {
+	var periods []abnormalPeriod
+	var current *abnormalPeriod
+	var currentStart, currentEnd time.Time
+	var worstLimit models.AlarmLimit
+
+	closePeriod := func() {
+		if current == nil {
+			return
+		}
+		current.Start = currentStart.Format("2006-01-02 15:04:05")
+		current.End = currentEnd.Format("2006-01-02 15:04:05")
+		current.DurationSeconds = currentEnd.Sub(currentStart).Seconds() + expectedSamplePeriod.Seconds()
+		current.Severity = alarmSeverity(current.Level)
+		current.Label = alarmLabel(worstLimit, current.Level)
+		if threshold := alarmThreshold(worstLimit, current.Level); threshold != nil {
+			current.Threshold = *threshold
+		}
+		current.Peak = current.Max
+		if isLowAlarm(current.Level) {
+			current.Peak = current.Min
+		}
+		periods = append(periods, *current)
+		current = nil
+	}
+
+	for i, ts := range times {
+		v := values[i]
+		limit := limitAt(i)
+		level := classifyAlarm(limit, v)
+		// Periode juga diputus jika nilai melompat dari sisi low ke sisi high (atau sebaliknya)
+		if current != nil && (level == alarmNormal || ts.Sub(currentEnd) > maxGap || isLowAlarm(level) != isLowAlarm(current.Level)) {
+			closePeriod()
+		}
+		if level == alarmNormal {
+			continue
+		}
+
+		if current == nil {
+			current = &abnormalPeriod{Field: field, Level: level, Min: v, Max: v}
+			currentStart = ts
+			worstLimit = limit
+		}
+		currentEnd = ts
+		if alarmRank(level) > alarmRank(current.Level) {
+			current.Level = level
+			worstLimit = limit
+		}
+		if v < current.Min {
+			current.Min = v
+		}
+		if v > current.Max {
+			current.Max = v
+		}
+	}
+	closePeriod()
+
+	return periods
+}
+
+// Ambil batas alarm aktif, bisa dibatasi ke beberapa field
+func getActiveAlarmLimits(fields []string) ([]models.AlarmLimit, error) {
+	var limits []models.AlarmLimit
+	query := config.DB.Where("active = ?", true).Order("field ASC")
+	if len(fields) > 0 {
+		query = query.Where("field IN ?", fields)
+	}
+	err := query.Find(&limits).Error
+	return limits, err
+}
+
+// Validasi batas alarm sebelum disimpan
+func validateAlarmLimit(l models.AlarmLimit) error {
+	if _, ok := pasteurColumns[l.Field]; !ok {
+		return fmt.Errorf("field %s tidak dikenal. Pilihan: %s", l.Field, strings.Join(pasteurFieldNames(), ", "))
+	}
+
+	// Urutan batas harus LL < L < H < HH untuk batas yang diisi
+	var set []float64
+	for _, v := range []*float64{l.LL, l.L, l.H, l.HH} {
+		if v != nil {
+			set = append(set, *v)
+		}
+	}
+	if len(set) == 0 {
+		return fmt.Errorf("minimal satu batas (ll, l, h, hh) harus diisi")
+	}
+	for i := 1; i < len(set); i++ {
+		if set[i] <= set[i-1] {
+			return fmt.Errorf("batas harus berurutan ll < l < h < hh")
+		}
+	}
+	return nil
+}
+
+// Nilai batas di body request. Set true jika key dikirim, null berarti batas dihapus.
+type optionalLimit struct {
+	Set   bool
+	Value *float64
+}
+
+func (o *optionalLimit) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	return json.Unmarshal(data, &o.Value)
+}
+
+// Input batas alarm. Semua field opsional supaya PUT hanya mengubah field yang dikirim,
+// misal {"active": true} untuk mengaktifkan batas bawaan.
+type alarmLimitInput struct {
+	Field       *string       `json:"field"`
+	LL          optionalLimit `json:"ll"`
+	L           optionalLimit `json:"l"`
+	H           optionalLimit `json:"h"`
+	HH          optionalLimit `json:"hh"`
+	Unit        *string       `json:"unit"`
+	Description *string       `json:"description"`
+	Active      *bool         `json:"active"`
+}
+
+func (in alarmLimitInput) apply(l *models.AlarmLimit) {
+	if in.Field != nil {
+		l.Field = strings.ToLower(strings.TrimSpace(*in.Field))
+	}
+	for _, f := range []struct {
+		in  optionalLimit
+		out **float64
+	}{{in.LL, &l.LL}, {in.L, &l.L}, {in.H, &l.H}, {in.HH, &l.HH}} {
+		if f.in.Set {
+			*f.out = f.in.Value
+		}
+	}
+	if in.Unit != nil {
+		l.Unit = *in.Unit
+	}
+	if in.Description != nil {
+		l.Description = *in.Description
+	}
+	if in.Active != nil {
+		l.Active = *in.Active
+	}
+}
+
+// GetAlarmLimits -> daftar batas alarm
+func GetAlarmLimits(c *gin.Context) {
+	var limits []models.AlarmLimit
+	query := config.DB.Order("field ASC")
+	if c.Query("active") == "true" {
+		query = query.Where("active = ?", true)
+	}
+
+	if err := query.Find(&limits).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil batas alarm", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "count": len(limits), "data": limits, "fields": pasteurFieldNames()})
+}
+
+// CreateAlarmLimit -> tambah batas alarm untuk satu field
+func CreateAlarmLimit(c *gin.Context) {
+	var input alarmLimitInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Body tidak valid", "error": err.Error()})
+		return
+	}
+
+	limit := models.AlarmLimit{Active: true}
+	input.apply(&limit)
+	if err := validateAlarmLimit(limit); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	// Field unik termasuk baris terhapus: field yang masih ada ditolak, yang pernah dihapus
+	// dipulihkan dengan nilai baru
+	var existing models.AlarmLimit
+	result := config.DB.Unscoped().Where("field = ?", limit.Field).Limit(1).Find(&existing)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan batas alarm", "error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected > 0 && !existing.DeletedAt.Valid {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": fmt.Sprintf("Batas alarm untuk field %s sudah ada, gunakan PUT untuk mengubah", limit.Field)})
+		return
+	}
+	if result.RowsAffected > 0 {
+		limit.ID = existing.ID
+		limit.CreatedAt = existing.CreatedAt
+		err := config.DB.Unscoped().Save(&limit).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan batas alarm", "error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"success": true, "data": limit})
+		return
+	}
+
+	if err := config.DB.Create(&limit).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan batas alarm", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": limit})
+}
+
+// UpdateAlarmLimit -> ubah batas alarm berdasarkan id
+func UpdateAlarmLimit(c *gin.Context) {
+	var limit models.AlarmLimit
+	if err := config.DB.First(&limit, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Batas alarm tidak ditemukan"})
+		return
+	}
+
+	var input alarmLimitInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Body tidak valid", "error": err.Error()})
+		return
+	}
+	input.apply(&limit)
+	if err := validateAlarmLimit(limit); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	// Field baru tidak boleh bentrok dengan batas lain (termasuk yang sudah dihapus)
+	var taken int64
+	if err := config.DB.Unscoped().Model(&models.AlarmLimit{}).Where("field = ? AND id <> ?", limit.Field, limit.ID).Count(&taken).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengubah batas alarm", "error": err.Error()})
+		return
+	}
+	if taken > 0 {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": fmt.Sprintf("Batas alarm untuk field %s sudah ada", limit.Field)})
+		return
+	}
+
+	if err := config.DB.Save(&limit).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengubah batas alarm", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": limit})
+}
+
+// DeleteAlarmLimit -> hapus batas alarm (soft delete, tidak diisi ulang oleh seed)
+func DeleteAlarmLimit(c *gin.Context) {
+	result := config.DB.Delete(&models.AlarmLimit{}, c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menghapus batas alarm", "error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Batas alarm tidak ditemukan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Batas alarm dihapus"})
+}
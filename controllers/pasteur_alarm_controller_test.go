@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"backend-golang/models"
+)
+
+func TestAlarmLimitInputApply(t *testing.T) {
+	base := func() models.AlarmLimit {
+		return models.AlarmLimit{Field: "level_bt1", LL: limitPtr(5), L: limitPtr(10), H: limitPtr(90), HH: limitPtr(95), Unit: "%"}
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		active bool
+		ll     *float64
+		h      *float64
+		unit   string
+	}{
+		{name: "hanya aktifkan", body: `{"active": true}`, active: true, ll: limitPtr(5), h: limitPtr(90), unit: "%"},
+		{name: "ubah satu batas", body: `{"h": 85}`, ll: limitPtr(5), h: limitPtr(85), unit: "%"},
+		{name: "null menghapus batas", body: `{"ll": null}`, h: limitPtr(90), unit: "%"},
+		{name: "ubah unit", body: `{"unit": "persen"}`, ll: limitPtr(5), h: limitPtr(90), unit: "persen"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input alarmLimitInput
+			if err := json.Unmarshal([]byte(tt.body), &input); err != nil {
+				t.Fatal(err)
+			}
+			limit := base()
+			input.apply(&limit)
+
+			if limit.Field != "level_bt1" {
+				t.Errorf("field = %q, want level_bt1", limit.Field)
+			}
+			if limit.Active != tt.active {
+				t.Errorf("active = %v, want %v", limit.Active, tt.active)
+			}
+			if !sameLimit(limit.LL, tt.ll) || !sameLimit(limit.H, tt.h) {
+				t.Errorf("ll/h = %v/%v, want %v/%v", limitValueOf(limit.LL), limitValueOf(limit.H), limitValueOf(tt.ll), limitValueOf(tt.h))
+			}
+			if limit.Unit != tt.unit {
+				t.Errorf("unit = %q, want %q", limit.Unit, tt.unit)
+			}
+		})
+	}
+}
+
+func TestAbnormalSeverity(t *testing.T) {
+	// Batas bawaan suhu heating: warning di luar 105-120, critical di luar 100-125
+	limit := models.AlarmLimit{Field: "suhu_heating", LL: limitPtr(100), L: limitPtr(105), H: limitPtr(120), HH: limitPtr(125)}
+	start := time.Date(2026, 1, 5, 8, 0, 0, 0, jakartaLoc)
+
+	tests := []struct {
+		name     string
+		values   []float64
+		periods  int
+		level    string
+		severity string
+		label    string
+		peak     float64
+	}{
+		{name: "normal, batas eksklusif", values: []float64{105, 110, 120}},
+		{name: "warning high", values: []float64{110, 121, 122, 110}, periods: 1, level: alarmHigh, severity: severityWarning, label: ">120", peak: 122},
+		{name: "naik ke critical dalam satu periode", values: []float64{110, 121, 126, 121, 110}, periods: 1, level: alarmHiHi, severity: severityCritical, label: ">125", peak: 126},
+		{name: "critical low", values: []float64{110, 104, 99, 110}, periods: 1, level: alarmLowLow, severity: severityCritical, label: "<100", peak: 99},
+		{name: "lompat low ke high jadi dua periode", values: []float64{104, 121}, periods: 2, level: alarmLow, severity: severityWarning, label: "<105", peak: 104},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			times := make([]time.Time, len(tt.values))
+			for i := range tt.values {
+				times[i] = start.Add(time.Duration(i) * time.Second)
+			}
+			periods := detectAbnormalPeriods(limit.Field, func(int) models.AlarmLimit { return limit }, times, tt.values, 10*time.Second)
+			if len(periods) != tt.periods {
+				t.Fatalf("periode = %d, want %d", len(periods), tt.periods)
+			}
+			if tt.periods == 0 {
+				return
+			}
+			p := periods[0]
+			if p.Level != tt.level || p.Severity != tt.severity || p.Label != tt.label || p.Peak != tt.peak {
+				t.Errorf("periode = %s/%s/%s peak %v, want %s/%s/%s peak %v", p.Level, p.Severity, p.Label, p.Peak, tt.level, tt.severity, tt.label, tt.peak)
+			}
+		})
+	}
+}
+
+func limitPtr(v float64) *float64 { return &v }
+
+func sameLimit(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func limitValueOf(v *float64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
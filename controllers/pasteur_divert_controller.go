@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"backend-golang/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Pembaca status divert dari Time_Divert sesuai konfigurasi HTST (divert_signal).
+// Mode counter membandingkan dengan sampel sebelumnya, jadi sampel harus diberikan berurutan.
+type divertSignal struct {
+	mode      string
+	threshold float64
+	maxGap    time.Duration
+	prev      float64
+	prevTs    time.Time
+}
+
+func newDivertSignal(cfg models.HTSTConfig, maxGap time.Duration) *divertSignal {
+	return &divertSignal{mode: cfg.DivertSignal, threshold: cfg.DivertThreshold, maxGap: maxGap}
+}
+
+func (s *divertSignal) diverting(ts time.Time, v float64) bool {
+	var diverting bool
+	if s.mode == models.DivertSignalCounter {
+		diverting = !s.prevTs.IsZero() && ts.Sub(s.prevTs) <= s.maxGap && v-s.prev > s.threshold
+	} else {
+		diverting = v > s.threshold
+	}
+	s.prev, s.prevTs = v, ts
+	return diverting
+}
+
+// Satu kejadian divert berurutan
+type divertEvent struct {
+	Start           string  `json:"start"`
+	End             string  `json:"end"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	HoldingTemp     float64 `json:"holding_temp"`    // SuhuHolding saat divert mulai
+	Flowrate        float64 `json:"flowrate"`        // Flowrate saat divert mulai
+	MaxTimeDivert   float64 `json:"max_time_divert"` // nilai Time_Divert terbesar selama event
+
+	startTs, endTs time.Time
+}
+
+// Pengelompok sampel divert menjadi event. Event yang masih terbuka di akhir satu
+// potongan data dibawa ke potongan berikutnya, jadi event yang melewati tengah malam
+// tetap satu event. Sampel forward flow atau jeda data lebih dari maxGap menutup event.
+type divertDetector struct {
+	signal  *divertSignal
+	maxGap  time.Duration
+	current *divertEvent
+	events  []divertEvent
+}
+
+func (d *divertDetector) closeEvent() {
+	if d.current == nil {
+		return
+	}
+	e := d.current
+	e.Start = e.startTs.Format("2006-01-02 15:04:05")
+	e.End = e.endTs.Format("2006-01-02 15:04:05")
+	e.DurationSeconds = e.endTs.Sub(e.startTs).Seconds() + expectedSamplePeriod.Seconds()
+	d.events = append(d.events, *e)
+	d.current = nil
+}
+
+func (d *divertDetector) feed(series pasteurSeries) {
+	temps, flows, diverts := series.Values["suhu_holding"], series.Values["flowrate"], series.Values["time_divert"]
+
+	for i, ts := range series.Times {
+		diverting := d.signal.diverting(ts, diverts[i])
+		if d.current != nil && (!diverting || ts.Sub(d.current.endTs) > d.maxGap) {
+			d.closeEvent()
+		}
+		if !diverting {
+			continue
+		}
+
+		if d.current == nil {
+			d.current = &divertEvent{HoldingTemp: temps[i], Flowrate: flows[i], startTs: ts}
+		}
+		d.current.endTs = ts
+		if diverts[i] > d.current.MaxTimeDivert {
+			d.current.MaxTimeDivert = diverts[i]
+		}
+	}
+}
+
+// Tutup event terakhir dan kembalikan semua event
+func (d *divertDetector) finish() []divertEvent {
+	d.closeEvent()
+	return d.events
+}
+
+// GetDivertEvents -> daftar event divert beserta jumlah dan total durasi per hari (from, to)
+func GetDivertEvents(c *gin.Context) {
+	fromDate, toDate, err := parseDateRangeDates(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	cfg, err := getHTSTConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Konfigurasi HTST belum ada", "error": err.Error()})
+		return
+	}
+
+	// Baca per hari supaya data per detik tidak dimuat sekaligus
+	maxGap := maxSampleGap()
+	detector := &divertDetector{signal: newDivertSignal(cfg, maxGap), maxGap: maxGap}
+	for d := fromDate; !d.After(toDate); d = d.AddDate(0, 0, 1) {
+		series, err := getPasteurSeries([]string{"suhu_holding", "flowrate", "time_divert"}, d, d.Add(24*time.Hour-time.Second))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": fmt.Sprintf("Gagal mengambil data %s", d.Format("2006-01-02")), "error": err.Error()})
+			return
+		}
+		detector.feed(series)
+	}
+	events := detector.finish()
+
+	// Event dihitung pada tanggal mulainya
+	type dailyDivert struct {
+		Date           string  `json:"date"`
+		Count          int     `json:"count"`
+		TotalSeconds   float64 `json:"total_seconds"`
+		LongestSeconds float64 `json:"longest_seconds"`
+	}
+	var daily []*dailyDivert
+	byDate := make(map[string]*dailyDivert)
+	for d := fromDate; !d.After(toDate); d = d.AddDate(0, 0, 1) {
+		day := &dailyDivert{Date: d.Format("2006-01-02")}
+		daily = append(daily, day)
+		byDate[day.Date] = day
+	}
+
+	var totalSeconds float64
+	for _, e := range events {
+		totalSeconds += e.DurationSeconds
+		day := byDate[e.startTs.Format("2006-01-02")]
+		if day == nil {
+			continue
+		}
+		day.Count++
+		day.TotalSeconds += e.DurationSeconds
+		if e.DurationSeconds > day.LongestSeconds {
+			day.LongestSeconds = e.DurationSeconds
+		}
+	}
+	if events == nil {
+		events = []divertEvent{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":                true,
+		"divert_signal":          cfg.DivertSignal,
+		"from":                   fromDate.Format("2006-01-02"),
+		"to":                     toDate.Format("2006-01-02"),
+		"count":                  len(events),
+		"total_diverted_seconds": totalSeconds,
+		"daily":                  daily,
+		"data":                   events,
+	})
+}
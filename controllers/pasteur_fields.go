@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"backend-golang/config"
+)
+
+// Whitelist nama JSON SensorPasteurisasi -> kolom di readsensors_pasteurisasi1.
+// Hanya field di sini yang boleh dipakai di query dinamis.
+var pasteurColumns = map[string]string{
+	"speed_pompa_mixing": "Speed_Pompa_Mixing",
+	"pressure_mixing":    "Pressure_Mixing",
+	"suhu_preheating":    "Suhu_P reheating",
+	"level_bt1":          "Level_BT1",
+	"speed_pump_bt1":     "Speed_Pump_BT1",
+	"level_vd":           "Level_VD",
+	"speed_pump_vd":      "Speed_Pump_VD",
+	"flowrate":           "Flowrate",
+	"suhu_heating":       "SuhuHeating",
+	"suhu_holding":       "SuhuHolding",
+	"suhu_precooling":    "SuhuPrecooling",
+	"level_bt2":          "Level_BT2",
+	"speed_pump_bt2":     "Speed_Pump_BT2",
+	"pressure_bt2":       "Pressure_BT2",
+	"suhu_cooling":       "SuhuCooling",
+	"press_to_pasteur":   "Press_To_Pasteur",
+	"vdhh":               "VDHH",
+	"vdll":               "VDLL",
+	"mixing_am":          "MixingAM",
+	"bt1_am":             "BT1AM",
+	"vd_am":              "VDAM",
+	"pcv1":               "PCV1",
+	"time_divert":        "Time_Divert",
+}
+
+// Kolom pasteur dalam backtick, wajib karena ada kolom dengan spasi ("Suhu_P reheating")
+func pasteurColumn(field string) (string, error) {
+	col, ok := pasteurColumns[field]
+	if !ok {
+		return "", fmt.Errorf("field %s tidak dikenal", field)
+	}
+	return "`" + col + "`", nil
+}
+
+// Daftar field yang valid, urut alfabet (untuk pesan error)
+func pasteurFieldNames() []string {
+	names := make([]string, 0, len(pasteurColumns))
+	for name := range pasteurColumns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse daftar field dipisah koma dan validasi terhadap whitelist
+func parsePasteurFields(param string) ([]string, error) {
+	var fields []string
+	seen := make(map[string]bool)
+	for _, f := range strings.Split(param, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" || seen[f] {
+			continue
+		}
+		if _, ok := pasteurColumns[f]; !ok {
+			return nil, fmt.Errorf("field %s tidak dikenal. Pilihan: %s", f, strings.Join(pasteurFieldNames(), ", "))
+		}
+		seen[f] = true
+		fields = append(fields, f)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("minimal satu field")
+	}
+	return fields, nil
+}
+
+// Data mentah pasteur untuk beberapa field, urut berdasarkan waktu
+type pasteurSeries struct {
+	Times  []time.Time
+	Values map[string][]float64
+}
+
+// Ambil data mentah beberapa field pasteur dalam rentang waktu
+func getPasteurSeries(fields []string, start, end time.Time) (pasteurSeries, error) {
+	series := pasteurSeries{Values: make(map[string][]float64, len(fields))}
+
+	cols := make([]string, 0, len(fields))
+	for _, f := range fields {
+		col, err := pasteurColumn(f)
+		if err != nil {
+			return series, err
+		}
+		cols = append(cols, col)
+	}
+
+	query := fmt.Sprintf("SELECT Waktu, %s FROM readsensors_pasteurisasi1 WHERE Waktu >= ? AND Waktu <= ? ORDER BY Waktu ASC",
+		strings.Join(cols, ", "))
+	rows, err := config.DB.Raw(query,
+		start.In(jakartaLoc).Format("2006-01-02 15:04:05"),
+		end.In(jakartaLoc).Format("2006-01-02 15:04:05"),
+	).Rows()
+	if err != nil {
+		return series, err
+	}
+	defer rows.Close()
+
+	values := make([]float64, len(fields))
+	dest := make([]interface{}, len(fields)+1)
+	var ts time.Time
+	dest[0] = &ts
+	for i := range values {
+		dest[i+1] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return series, err
+		}
+		series.Times = append(series.Times, toJakartaWall(ts))
+		for i, f := range fields {
+			series.Values[f] = append(series.Values[f], values[i])
+		}
+	}
+	return series, rows.Err()
+}
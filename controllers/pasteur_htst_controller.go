@@ -0,0 +1,535 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"backend-golang/config"
+	"backend-golang/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Batas rentang laporan compliance (data per detik dibaca per hari)
+const maxHTSTRangeDays = 31
+
+// Status compliance harian. Unverifiable = data tidak cukup untuk membuktikan compliance
+// (kelengkapan data tidak good atau ada jeda data saat produksi).
+const (
+	htstStatusCompliant    = "compliant"
+	htstStatusNonCompliant = "non_compliant"
+	htstStatusUnverifiable = "unverifiable"
+)
+
+// Jenis pelanggaran HTST
+const (
+	htstUnderTemperature = "under_temperature" // SuhuHolding di bawah minimal saat produksi
+	htstShortHolding     = "short_holding"     // flowrate terlalu tinggi, waktu tahan kurang
+)
+
+// Satu periode produksi (flowrate di atas batas minimal)
+type htstProductionPeriod struct {
+	Start           string  `json:"start"`
+	End             string  `json:"end"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Liters          float64 `json:"liters"`
+	MinHoldingTemp  float64 `json:"min_holding_temp"`
+}
+
+// Satu interval pelanggaran beserta reaksi divert
+type htstInterval struct {
+	Type                  string   `json:"type"`
+	Start                 string   `json:"start"`
+	End                   string   `json:"end"`
+	DurationSeconds       float64  `json:"duration_seconds"`
+	MinHoldingTemp        float64  `json:"min_holding_temp"`
+	MaxFlowrate           float64  `json:"max_flowrate"`
+	Liters                float64  `json:"liters"`
+	LitersForwarded       float64  `json:"liters_forwarded"`                // liter yang lewat saat divert tidak aktif
+	LitersForwardedLate   float64  `json:"liters_forwarded_after_reaction"` // harus 0 supaya compliant
+	DivertHeld            bool     `json:"divert_held"`                     // divert tetap aktif sampai suhu pulih
+	DivertReacted         bool     `json:"divert_reacted"`
+	DivertReactionSeconds *float64 `json:"divert_reaction_seconds"`
+	Compliant             bool     `json:"compliant"`
+}
+
+// Ringkasan compliance satu hari
+type htstDaySummary struct {
+	Date                string                 `json:"date"`
+	Config              models.HTSTConfig      `json:"config"`
+	ProductionMinutes   float64                `json:"production_minutes"`
+	ProductionLiters    float64                `json:"production_liters"`
+	MinHoldingTemp      *float64               `json:"min_holding_temp"` // selama produksi
+	CompliantPercentage float64                `json:"compliant_percentage"`
+	IntervalCount       int                    `json:"interval_count"`
+	DivertedCount       int                    `json:"diverted_count"`
+	NonCompliantCount   int                    `json:"non_compliant_count"`
+	ProductionDataGaps  int                    `json:"production_data_gaps"` // jeda data > maxGap saat produksi
+	LitersAtRisk        float64                `json:"liters_at_risk"`
+	Status              string                 `json:"status"`
+	Compliant           bool                   `json:"compliant"`
+	Complete            bool                   `json:"complete"` // hari sudah selesai
+	DataQuality         gin.H                  `json:"data_quality"`
+	ProductionPeriods   []htstProductionPeriod `json:"production_periods"`
+	Intervals           []htstInterval         `json:"intervals"`
+}
+
+// Ambil konfigurasi HTST aktif
+func getHTSTConfig() (models.HTSTConfig, error) {
+	var cfg models.HTSTConfig
+	err := config.DB.Order("id ASC").First(&cfg).Error
+	return cfg, err
+}
+
+// Waktu tahan di holding tube (detik) untuk flowrate L/jam
+func holdingSeconds(cfg models.HTSTConfig, flowrate float64) float64 {
+	if flowrate <= 0 {
+		return 0
+	}
+	return cfg.HoldingTubeLiters / (flowrate / 3600)
+}
+
+// Pembangun interval pelanggaran dari sampel berurutan
+type htstIntervalBuilder struct {
+	typ           string
+	reactionLimit float64 // detik
+	lateForward   bool    // ada sampel forward flow setelah batas reaksi
+	current       *htstInterval
+	start, last   time.Time
+	firstDivert   time.Time
+	intervals     []htstInterval
+}
+
+func (b *htstIntervalBuilder) add(ts time.Time, dt, temp, flow float64, diverting bool) {
+	if b.current == nil {
+		b.current = &htstInterval{Type: b.typ, MinHoldingTemp: temp, MaxFlowrate: flow}
+		b.start = ts
+		b.firstDivert = time.Time{}
+		b.lateForward = false
+	}
+	b.last = ts
+
+	liters := flow * dt / 3600
+	b.current.DurationSeconds += dt
+	b.current.Liters += liters
+	if temp < b.current.MinHoldingTemp {
+		b.current.MinHoldingTemp = temp
+	}
+	if flow > b.current.MaxFlowrate {
+		b.current.MaxFlowrate = flow
+	}
+	if diverting {
+		if b.firstDivert.IsZero() {
+			b.firstDivert = ts
+		}
+	} else {
+		b.current.LitersForwarded += liters
+		if ts.Sub(b.start).Seconds() > b.reactionLimit {
+			b.current.LitersForwardedLate += liters
+			b.lateForward = true
+		}
+	}
+}
+
+// Interval compliant jika divert aktif dalam batas reaksi dan tidak kembali ke forward
+// flow selama suhu/waktu tahan belum pulih
+func (b *htstIntervalBuilder) close() {
+	if b.current == nil {
+		return
+	}
+	b.current.Start = b.start.Format("2006-01-02 15:04:05")
+	b.current.End = b.last.Format("2006-01-02 15:04:05")
+	if !b.firstDivert.IsZero() {
+		reaction := b.firstDivert.Sub(b.start).Seconds()
+		b.current.DivertReacted = true
+		b.current.DivertReactionSeconds = &reaction
+		b.current.DivertHeld = !b.lateForward
+		b.current.Compliant = reaction <= b.reactionLimit && !b.lateForward
+	}
+	b.intervals = append(b.intervals, *b.current)
+	b.current = nil
+}
+
+// Hitung compliance HTST satu hari dari SuhuHolding, Flowrate dan Time_Divert
+func calculateHTSTDay(date time.Time, cfg models.HTSTConfig, now time.Time) (htstDaySummary, error) {
+	dayEnd := date.Add(24*time.Hour - time.Second)
+	summary := htstDaySummary{
+		Date:     date.Format("2006-01-02"),
+		Config:   cfg,
+		Complete: !now.Before(date.Add(24 * time.Hour)),
+
+		ProductionPeriods: []htstProductionPeriod{},
+	}
+
+	series, err := getPasteurSeries([]string{"suhu_holding", "flowrate", "time_divert"}, date, dayEnd)
+	if err != nil {
+		return summary, err
+	}
+	temps, flows, diverts := series.Values["suhu_holding"], series.Values["flowrate"], series.Values["time_divert"]
+	maxGap := maxSampleGap()
+
+	var period *htstProductionPeriod
+	var periodStart, periodLast time.Time
+	closePeriod := func() {
+		if period == nil {
+			return
+		}
+		period.Start = periodStart.Format("2006-01-02 15:04:05")
+		period.End = periodLast.Format("2006-01-02 15:04:05")
+		summary.ProductionPeriods = append(summary.ProductionPeriods, *period)
+		period = nil
+	}
+
+	signal := newDivertSignal(cfg, maxGap)
+	under := &htstIntervalBuilder{typ: htstUnderTemperature, reactionLimit: cfg.DivertReactionSeconds}
+	short := &htstIntervalBuilder{typ: htstShortHolding, reactionLimit: cfg.DivertReactionSeconds}
+	var compliantSeconds, productionSeconds float64
+
+	for i, ts := range series.Times {
+		next := ts.Add(expectedSamplePeriod)
+		if i+1 < len(series.Times) {
+			next = series.Times[i+1]
+		}
+		dt := sampleSpan(ts, next, maxGap).Seconds()
+		temp, flow, diverting := temps[i], flows[i], signal.diverting(ts, diverts[i])
+
+		// Jeda data memutus periode dan interval, jeda saat produksi tidak bisa dibuktikan
+		if i > 0 && ts.Sub(series.Times[i-1]) > maxGap {
+			if period != nil {
+				summary.ProductionDataGaps++
+			}
+			closePeriod()
+			under.close()
+			short.close()
+		}
+
+		if flow < cfg.MinProductionFlowrate {
+			closePeriod()
+			under.close()
+			short.close()
+			continue
+		}
+
+		if period == nil {
+			period = &htstProductionPeriod{MinHoldingTemp: temp}
+			periodStart = ts
+		}
+		periodLast = ts
+		period.DurationSeconds += dt
+		period.Liters += flow * dt / 3600
+		if temp < period.MinHoldingTemp {
+			period.MinHoldingTemp = temp
+		}
+		if summary.MinHoldingTemp == nil || temp < *summary.MinHoldingTemp {
+			t := temp
+			summary.MinHoldingTemp = &t
+		}
+		productionSeconds += dt
+
+		underTemp := temp < cfg.MinHoldingTemp
+		shortHold := cfg.HoldingTubeLiters > 0 && holdingSeconds(cfg, flow) < cfg.MinHoldingSeconds
+		if underTemp {
+			under.add(ts, dt, temp, flow, diverting)
+		} else {
+			under.close()
+		}
+		if shortHold {
+			short.add(ts, dt, temp, flow, diverting)
+		} else {
+			short.close()
+		}
+		if !underTemp && !shortHold {
+			compliantSeconds += dt
+		}
+	}
+	closePeriod()
+	under.close()
+	short.close()
+
+	summary.Intervals = append(append([]htstInterval{}, under.intervals...), short.intervals...)
+	for _, p := range summary.ProductionPeriods {
+		summary.ProductionLiters += p.Liters
+	}
+	summary.ProductionMinutes = productionSeconds / 60
+	if productionSeconds > 0 {
+		summary.CompliantPercentage = compliantSeconds / productionSeconds * 100
+	}
+	for _, iv := range summary.Intervals {
+		summary.IntervalCount++
+		if iv.DivertReacted {
+			summary.DivertedCount++
+		}
+		if !iv.Compliant {
+			summary.NonCompliantCount++
+		}
+		summary.LitersAtRisk += iv.LitersForwarded
+	}
+	summary.DataQuality = dataQuality(expectedSamples(date, dayEnd, now), int64(len(series.Times)))
+
+	// Data yang hilang bukan bukti compliance
+	switch {
+	case summary.NonCompliantCount > 0:
+		summary.Status = htstStatusNonCompliant
+	case summary.DataQuality["status"] != "good" || summary.ProductionDataGaps > 0:
+		summary.Status = htstStatusUnverifiable
+	default:
+		summary.Status = htstStatusCompliant
+	}
+	summary.Compliant = summary.Status == htstStatusCompliant
+
+	return summary, nil
+}
+
+// GetHTSTCompliance -> ringkasan compliance per hari (from, to)
+func GetHTSTCompliance(c *gin.Context) {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	fromDate, _ := time.ParseInLocation("2006-01-02", from, jakartaLoc)
+	toDate, _ := time.ParseInLocation("2006-01-02", to, jakartaLoc)
+	if toDate.Sub(fromDate) > maxHTSTRangeDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": fmt.Sprintf("rentang tanggal maksimal %d hari", maxHTSTRangeDays)})
+		return
+	}
+
+	cfg, err := getHTSTConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Konfigurasi HTST belum ada", "error": err.Error()})
+		return
+	}
+
+	var signoffs []models.HTSTSignoff
+	if err := config.DB.Where("date BETWEEN ? AND ?", from, to).Find(&signoffs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil sign-off", "error": err.Error()})
+		return
+	}
+	signed := make(map[string]models.HTSTSignoff, len(signoffs))
+	for _, s := range signoffs {
+		signed[s.Date] = s
+	}
+
+	now := time.Now().In(jakartaLoc)
+	var days []gin.H
+	for d := fromDate; !d.After(toDate); d = d.AddDate(0, 0, 1) {
+		summary, err := calculateHTSTDay(d, cfg, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": fmt.Sprintf("Gagal menghitung compliance %s", d.Format("2006-01-02")), "error": err.Error()})
+			return
+		}
+
+		day := gin.H{"summary": summary, "signoff": nil}
+		if s, ok := signed[summary.Date]; ok {
+			day["signoff"] = gin.H{
+				"id":        s.ID,
+				"signed_by": s.SignedBy,
+				"signed_at": s.SignedAt,
+				"note":      s.Note,
+				"compliant": s.Compliant,
+				"status":    s.Status,
+				"override":  s.OverrideNote != "",
+			}
+		}
+		days = append(days, day)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "from": from, "to": to, "days": days})
+}
+
+// GetHTSTConfig -> konfigurasi compliance aktif
+func GetHTSTConfig(c *gin.Context) {
+	cfg, err := getHTSTConfig()
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Konfigurasi HTST belum ada"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": cfg})
+}
+
+// Input perubahan konfigurasi HTST. Parameter pointer supaya parameter yang tidak dikirim
+// tetap (misal holding_tube_liters tidak diam-diam menjadi 0 dan mematikan cek waktu tahan).
+// updated_by wajib supaya setiap perubahan bukti audit tercatat pelakunya.
+type htstConfigInput struct {
+	MinHoldingTemp        *float64 `json:"min_holding_temp"`
+	MinHoldingSeconds     *float64 `json:"min_holding_seconds"`
+	HoldingTubeLiters     *float64 `json:"holding_tube_liters"`
+	MinProductionFlowrate *float64 `json:"min_production_flowrate"`
+	DivertReactionSeconds *float64 `json:"divert_reaction_seconds"`
+	DivertSignal          *string  `json:"divert_signal"`
+	DivertThreshold       *float64 `json:"divert_threshold"`
+	UpdatedBy             string   `json:"updated_by" binding:"required"`
+}
+
+func (in htstConfigInput) apply(cfg *models.HTSTConfig) {
+	for _, f := range []struct {
+		in  *float64
+		out *float64
+	}{
+		{in.MinHoldingTemp, &cfg.MinHoldingTemp},
+		{in.MinHoldingSeconds, &cfg.MinHoldingSeconds},
+		{in.HoldingTubeLiters, &cfg.HoldingTubeLiters},
+		{in.MinProductionFlowrate, &cfg.MinProductionFlowrate},
+		{in.DivertReactionSeconds, &cfg.DivertReactionSeconds},
+		{in.DivertThreshold, &cfg.DivertThreshold},
+	} {
+		if f.in != nil {
+			*f.out = *f.in
+		}
+	}
+	if in.DivertSignal != nil {
+		cfg.DivertSignal = *in.DivertSignal
+	}
+	cfg.UpdatedBy = strings.TrimSpace(in.UpdatedBy)
+}
+
+// Validasi konfigurasi HTST setelah perubahan diterapkan
+func validateHTSTConfig(cfg models.HTSTConfig) error {
+	if cfg.MinHoldingTemp <= 0 || cfg.MinHoldingSeconds < 0 || cfg.HoldingTubeLiters < 0 ||
+		cfg.MinProductionFlowrate < 0 || cfg.DivertReactionSeconds < 0 {
+		return fmt.Errorf("min_holding_temp harus lebih dari 0 dan parameter lain tidak boleh negatif")
+	}
+	if cfg.DivertSignal != models.DivertSignalLevel && cfg.DivertSignal != models.DivertSignalCounter {
+		return fmt.Errorf("divert_signal harus level atau counter")
+	}
+	if cfg.DivertThreshold < 0 {
+		return fmt.Errorf("divert_threshold tidak boleh negatif")
+	}
+	if cfg.UpdatedBy == "" {
+		return fmt.Errorf("updated_by wajib diisi")
+	}
+	return nil
+}
+
+// UpdateHTSTConfig -> ubah konfigurasi compliance (hanya parameter yang dikirim)
+func UpdateHTSTConfig(c *gin.Context) {
+	cfg, err := getHTSTConfig()
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Konfigurasi HTST belum ada"})
+		return
+	}
+
+	var input htstConfigInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Body tidak valid, updated_by wajib diisi", "error": err.Error()})
+		return
+	}
+	input.apply(&cfg)
+	if err := validateHTSTConfig(cfg); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	if err := config.DB.Save(&cfg).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengubah konfigurasi", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": cfg})
+}
+
+// SignOffHTSTDay -> tanda tangan ringkasan compliance satu hari yang sudah selesai
+func SignOffHTSTDay(c *gin.Context) {
+	var input struct {
+		Date         string `json:"date" binding:"required"`
+		SignedBy     string `json:"signed_by" binding:"required"`
+		Note         string `json:"note"`
+		OverrideNote string `json:"override_note"` // wajib untuk hari unverifiable
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "date dan signed_by wajib diisi", "error": err.Error()})
+		return
+	}
+	date, err := time.ParseInLocation("2006-01-02", input.Date, jakartaLoc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format date salah. Gunakan YYYY-MM-DD"})
+		return
+	}
+
+	now := time.Now().In(jakartaLoc)
+	if now.Before(date.Add(24 * time.Hour)) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Hanya hari yang sudah selesai yang bisa ditandatangani"})
+		return
+	}
+
+	var existing int64
+	if err := config.DB.Model(&models.HTSTSignoff{}).Where("date = ?", input.Date).Count(&existing).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengecek sign-off", "error": err.Error()})
+		return
+	}
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Tanggal ini sudah ditandatangani"})
+		return
+	}
+
+	cfg, err := getHTSTConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Konfigurasi HTST belum ada", "error": err.Error()})
+		return
+	}
+	summary, err := calculateHTSTDay(date, cfg, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menghitung compliance", "error": err.Error()})
+		return
+	}
+	input.OverrideNote = strings.TrimSpace(input.OverrideNote)
+	if summary.Status == htstStatusUnverifiable && input.OverrideNote == "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"success": false,
+			"message": "Data hari ini tidak lengkap (unverifiable). Isi override_note untuk tetap menandatangani",
+			"data":    summary,
+		})
+		return
+	}
+	if summary.Status != htstStatusUnverifiable {
+		input.OverrideNote = ""
+	}
+
+	snapshot, err := json.Marshal(summary)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan ringkasan", "error": err.Error()})
+		return
+	}
+
+	signoff := models.HTSTSignoff{
+		Date:         input.Date,
+		Compliant:    summary.Compliant,
+		Status:       summary.Status,
+		SignedBy:     input.SignedBy,
+		Note:         input.Note,
+		OverrideNote: input.OverrideNote,
+		Summary:      string(snapshot),
+		SignedAt:     now,
+	}
+	if err := config.DB.Create(&signoff).Error; err != nil {
+		// Sign-off bersamaan untuk tanggal yang sama kena unique index date
+		var count int64
+		if config.DB.Model(&models.HTSTSignoff{}).Where("date = ?", input.Date).Count(&count).Error == nil && count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Tanggal ini sudah ditandatangani"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan sign-off", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": signoff})
+}
+
+// GetHTSTSignoffs -> daftar sign-off dalam rentang tanggal
+func GetHTSTSignoffs(c *gin.Context) {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	var signoffs []models.HTSTSignoff
+	if err := config.DB.Where("date BETWEEN ? AND ?", from, to).Order("date ASC").Find(&signoffs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil sign-off", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "count": len(signoffs), "data": signoffs})
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"backend-golang/models"
+)
+
+// Satu sampel per detik: suhu rendah dengan status divert per sampel
+type htstSample struct {
+	temp      float64
+	diverting bool
+}
+
+func TestHTSTIntervalBuilder(t *testing.T) {
+	start := time.Date(2026, 1, 5, 8, 0, 0, 0, jakartaLoc)
+	samples := func(n, divertFrom int, forwardAgain int) []htstSample {
+		s := make([]htstSample, n)
+		for i := range s {
+			s[i] = htstSample{temp: 71 - float64(i)*0.1, diverting: i >= divertFrom && (forwardAgain == 0 || i < forwardAgain)}
+		}
+		return s
+	}
+
+	tests := []struct {
+		name      string
+		samples   []htstSample
+		reacted   bool
+		reaction  float64
+		held      bool
+		compliant bool
+		late      float64
+	}{
+		{name: "divert dalam batas reaksi", samples: samples(10, 2, 0), reacted: true, reaction: 2, held: true, compliant: true},
+		{name: "divert terlambat", samples: samples(10, 7, 0), reacted: true, reaction: 7, held: false, late: 1},
+		{name: "divert lepas sebelum suhu pulih", samples: samples(10, 1, 8), reacted: true, reaction: 1, late: 2},
+		{name: "tanpa divert", samples: samples(4, 99, 0), late: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Flowrate 3600 L/jam = 1 liter per detik
+			b := &htstIntervalBuilder{typ: htstUnderTemperature, reactionLimit: 5}
+			for i, s := range tt.samples {
+				b.add(start.Add(time.Duration(i)*time.Second), 1, s.temp, 3600, s.diverting)
+			}
+			b.close()
+
+			if len(b.intervals) != 1 {
+				t.Fatalf("interval = %d, want 1", len(b.intervals))
+			}
+			iv := b.intervals[0]
+			if iv.DurationSeconds != float64(len(tt.samples)) || iv.Liters != float64(len(tt.samples)) {
+				t.Errorf("durasi/liter = %v/%v, want %d", iv.DurationSeconds, iv.Liters, len(tt.samples))
+			}
+			if iv.DivertReacted != tt.reacted || iv.Compliant != tt.compliant {
+				t.Errorf("reacted/compliant = %v/%v, want %v/%v", iv.DivertReacted, iv.Compliant, tt.reacted, tt.compliant)
+			}
+			if tt.reacted && (iv.DivertReactionSeconds == nil || *iv.DivertReactionSeconds != tt.reaction || iv.DivertHeld != tt.held) {
+				t.Errorf("reaksi = %v held %v, want %v held %v", limitValueOf(iv.DivertReactionSeconds), iv.DivertHeld, tt.reaction, tt.held)
+			}
+			if iv.LitersForwardedLate != tt.late {
+				t.Errorf("liter forward terlambat = %v, want %v", iv.LitersForwardedLate, tt.late)
+			}
+		})
+	}
+}
+
+func TestHTSTConfigInputApply(t *testing.T) {
+	existing := models.HTSTConfig{MinHoldingTemp: 72, MinHoldingSeconds: 15, HoldingTubeLiters: 50, MinProductionFlowrate: 500, DivertReactionSeconds: 5, DivertSignal: models.DivertSignalLevel, DivertThreshold: 0.5, UpdatedBy: "qa"}
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+		check   func(models.HTSTConfig) bool
+	}{
+		{name: "parameter yang tidak dikirim tetap", body: `{"min_holding_temp": 73, "updated_by": "spv"}`, check: func(c models.HTSTConfig) bool {
+			return c.MinHoldingTemp == 73 && c.HoldingTubeLiters == 50 && c.DivertThreshold == 0.5 && c.UpdatedBy == "spv"
+		}},
+		{name: "divert_signal tidak valid", body: `{"divert_signal": "pulse", "updated_by": "spv"}`, wantErr: true},
+		{name: "updated_by kosong", body: `{"holding_tube_liters": 60, "updated_by": "  "}`, wantErr: true},
+		{name: "nilai negatif", body: `{"divert_threshold": -1, "updated_by": "spv"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input htstConfigInput
+			if err := json.Unmarshal([]byte(tt.body), &input); err != nil {
+				t.Fatal(err)
+			}
+			cfg := existing
+			input.apply(&cfg)
+			err := validateHTSTConfig(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.check != nil && !tt.check(cfg) {
+				t.Errorf("config = %+v", cfg)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"backend-golang/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Ukuran bucket yang diizinkan (detik)
+var seriesBuckets = map[string]int{
+	"10s": 10,
+	"1m":  60,
+	"5m":  300,
+	"1h":  3600,
+}
+
+// Ekspresi SQL per agregasi, %s diganti kolom. last = nilai dengan Waktu terbesar dalam bucket.
+var seriesAggregations = map[string]string{
+	"avg":    "AVG(%s)",
+	"min":    "MIN(%s)",
+	"max":    "MAX(%s)",
+	"stddev": "STDDEV_POP(%s)",
+	"last":   "SUBSTRING_INDEX(GROUP_CONCAT(%s ORDER BY Waktu DESC), ',', 1) + 0",
+}
+
+// Batas jumlah bucket per request supaya query tidak terlalu berat
+const maxSeriesPoints = 20000
+
+// Parse daftar agregasi dipisah koma
+func parseSeriesAggs(param string) ([]string, error) {
+	var aggs []string
+	seen := make(map[string]bool)
+	for _, a := range strings.Split(param, ",") {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a == "" || seen[a] {
+			continue
+		}
+		if _, ok := seriesAggregations[a]; !ok {
+			return nil, fmt.Errorf("agg %s tidak dikenal. Gunakan avg, min, max, stddev, last", a)
+		}
+		seen[a] = true
+		aggs = append(aggs, a)
+	}
+	if len(aggs) == 0 {
+		return nil, fmt.Errorf("minimal satu agg")
+	}
+	return aggs, nil
+}
+
+// Satu titik series: timestamp awal bucket dan nilai per field per agregasi
+type seriesPoint struct {
+	Timestamp string
+	Values    map[string]map[string]*float64
+}
+
+// Agregasi beberapa field pasteur per bucket waktu dalam satu query
+func queryPasteurSeries(fields, aggs []string, bucketSeconds int, start, end time.Time) ([]seriesPoint, error) {
+	if points := int(end.Sub(start).Seconds())/bucketSeconds + 1; points > maxSeriesPoints {
+		return nil, fmt.Errorf("rentang terlalu panjang untuk bucket ini (%d titik, maksimal %d)", points, maxSeriesPoints)
+	}
+
+	bucketExpr := fmt.Sprintf("FROM_UNIXTIME(FLOOR(UNIX_TIMESTAMP(Waktu) / %d) * %d)", bucketSeconds, bucketSeconds)
+	selects := []string{fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m-%%d %%H:%%i:%%s') AS timestamp", bucketExpr)}
+	for _, f := range fields {
+		col, err := pasteurColumn(f)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range aggs {
+			selects = append(selects, fmt.Sprintf(seriesAggregations[a], col))
+		}
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM readsensors_pasteurisasi1
+		WHERE Waktu >= ? AND Waktu <= ?
+		GROUP BY timestamp
+		ORDER BY timestamp ASC
+	`, strings.Join(selects, ", "))
+
+	rows, err := config.DB.Raw(query,
+		start.Format("2006-01-02 15:04:05"),
+		end.Format("2006-01-02 15:04:05"),
+	).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []seriesPoint
+	values := make([]sql.NullFloat64, len(fields)*len(aggs))
+	dest := make([]interface{}, len(values)+1)
+	var timestamp string
+	dest[0] = &timestamp
+	for i := range values {
+		dest[i+1] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		point := seriesPoint{Timestamp: timestamp, Values: make(map[string]map[string]*float64, len(fields))}
+		for fi, f := range fields {
+			point.Values[f] = make(map[string]*float64, len(aggs))
+			for ai, a := range aggs {
+				if v := values[fi*len(aggs)+ai]; v.Valid {
+					val := v.Float64
+					point.Values[f][a] = &val
+				} else {
+					point.Values[f][a] = nil
+				}
+			}
+		}
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
+
+// GetPasteurSeries -> agregasi beberapa field per bucket waktu (fields, bucket, agg)
+func GetPasteurSeries(c *gin.Context) {
+	fields, err := parsePasteurFields(c.Query("fields"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	bucket := c.DefaultQuery("bucket", "1m")
+	bucketSeconds, ok := seriesBuckets[bucket]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "bucket harus 10s, 1m, 5m atau 1h"})
+		return
+	}
+
+	aggs, err := parseSeriesAggs(c.DefaultQuery("agg", "avg"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	startTime, endTime, err := getTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid date format. Use: YYYY-MM-DD HH:MM:SS or YYYY-MM-DDTHH:MM:SS",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	points, err := queryPasteurSeries(fields, aggs, bucketSeconds, startTime, endTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil series", "error": err.Error()})
+		return
+	}
+
+	data := make([]gin.H, 0, len(points))
+	for _, p := range points {
+		item := gin.H{"timestamp": p.Timestamp}
+		for f, v := range p.Values {
+			item[f] = v
+		}
+		data = append(data, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"fields":  fields,
+		"bucket":  bucket,
+		"agg":     aggs,
+		"count":   len(data),
+		"data":    data,
+		"filter": gin.H{
+			"start_date": startTime.Format("2006-01-02 15:04:05"),
+			"end_date":   endTime.Format("2006-01-02 15:04:05"),
+			"timezone":   "Asia/Jakarta (WIB)",
+		},
+	})
+}
+
+// Handler rata-rata per menit satu field, format response lama ({timestamp, average})
+func pasteurAverageHandler(field, label string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Dapatkan time range
+		startTime, endTime, err := getTimeRange(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid date format. Use: YYYY-MM-DD HH:MM:SS or YYYY-MM-DDTHH:MM:SS",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		points, err := queryPasteurSeries([]string{field}, []string{"avg"}, seriesBuckets["1m"], startTime, endTime)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   fmt.Sprintf("Failed to fetch average %s data", label),
+				"details": err.Error(),
+			})
+			return
+		}
+
+		results := make([]AvgResponse, 0, len(points))
+		for _, p := range points {
+			avg := p.Values[field]["avg"]
+			if avg == nil {
+				continue
+			}
+			results = append(results, AvgResponse{Timestamp: p.Timestamp, Average: *avg})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   results,
+			"count":  len(results),
+			"filter": gin.H{
+				"start_date": startTime.Format("2006-01-02 15:04:05"),
+				"end_date":   endTime.Format("2006-01-02 15:04:05"),
+				"timezone":   "Asia/Jakarta (WIB)",
+			},
+		})
+	}
+}
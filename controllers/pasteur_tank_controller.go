@@ -0,0 +1,291 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend-golang/config"
+	"backend-golang/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tipe event tank
+const (
+	tankOverflow = "overflow" // level di atas HH
+	tankRunDry   = "run_dry"  // level di bawah LL
+)
+
+// Batas rentang analitik tank (data per detik)
+const maxTankRange = 7 * 24 * time.Hour
+
+// Window default untuk laju saat ini (menit)
+const defaultTankRateWindow = 5
+
+// Tank pasteurisasi dan field level-nya
+var pasteurTanks = []struct {
+	Name  string
+	Field string
+}{
+	{"bt1", "level_bt1"},
+	{"bt2", "level_bt2"},
+	{"vd", "level_vd"},
+}
+
+func isPasteurTank(name string) bool {
+	for _, t := range pasteurTanks {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Rata-rata laju naik dan turun (%/menit) dari rata-rata level per menit.
+// Hanya menit yang berurutan yang dibandingkan.
+func tankFillDrainRates(times []time.Time, levels []float64) (fill, drain float64) {
+	var minutes []time.Time
+	var means []float64
+	var sum float64
+	var n int
+	for i, ts := range times {
+		m := ts.Truncate(time.Minute)
+		if len(minutes) > 0 && m.Equal(minutes[len(minutes)-1]) {
+			sum += levels[i]
+			n++
+			continue
+		}
+		if n > 0 {
+			means = append(means, sum/float64(n))
+		}
+		minutes = append(minutes, m)
+		sum, n = levels[i], 1
+	}
+	if n > 0 {
+		means = append(means, sum/float64(n))
+	}
+
+	var fillSum, drainSum float64
+	var fillCount, drainCount int
+	for i := 1; i < len(means); i++ {
+		if minutes[i].Sub(minutes[i-1]) != time.Minute {
+			continue
+		}
+		switch delta := means[i] - means[i-1]; {
+		case delta > 0:
+			fillSum += delta
+			fillCount++
+		case delta < 0:
+			drainSum -= delta
+			drainCount++
+		}
+	}
+	if fillCount > 0 {
+		fill = fillSum / float64(fillCount)
+	}
+	if drainCount > 0 {
+		drain = drainSum / float64(drainCount)
+	}
+	return fill, drain
+}
+
+// Laju level saat ini (%/menit): slope regresi linear sampel dalam window terakhir
+func tankCurrentRate(times []time.Time, levels []float64, window time.Duration) (float64, bool) {
+	if len(times) < 2 {
+		return 0, false
+	}
+	from := times[len(times)-1].Add(-window)
+	var n, sumX, sumY, sumXY, sumXX float64
+	for i := len(times) - 1; i >= 0 && !times[i].Before(from); i-- {
+		x := times[i].Sub(from).Minutes()
+		n++
+		sumX += x
+		sumY += levels[i]
+		sumXY += x * levels[i]
+		sumXX += x * x
+	}
+	denom := n*sumXX - sumX*sumX
+	if n < 2 || denom == 0 {
+		return 0, false
+	}
+	return (n*sumXY - sumX*sumY) / denom, true
+}
+
+// Analitik satu tank dalam rentang waktu (hanya baca, event disimpan oleh recorder)
+func analyzeTank(name, field string, limit models.AlarmLimit, series pasteurSeries, rateWindow time.Duration) gin.H {
+	levels := series.Values[field]
+	result := gin.H{"tank": name, "field": field, "samples": len(levels)}
+	if len(levels) == 0 {
+		result["events"] = []models.TankEvent{}
+		return result
+	}
+
+	// level_vd mengikuti setpoint VDLL/VDHH jika LL/HH tidak diisi
+	limitAt := sampleLimits(limit, series)
+	last := len(levels) - 1
+	current := levels[last]
+	currentLimit := limitAt(last)
+
+	fill, drain := tankFillDrainRates(series.Times, levels)
+	rate, ok := tankCurrentRate(series.Times, levels, rateWindow)
+
+	// Waktu sampai penuh (HH, atau 100%) / kosong (LL, atau 0%) pada laju saat ini
+	full, empty := 100.0, 0.0
+	if currentLimit.HH != nil {
+		full = *currentLimit.HH
+	}
+	if currentLimit.LL != nil {
+		empty = *currentLimit.LL
+	}
+	var toFull, toEmpty *float64
+	if ok && rate > 0 && current < full {
+		v := (full - current) / rate
+		toFull = &v
+	}
+	if ok && rate < 0 && current > empty {
+		v := (current - empty) / -rate
+		toEmpty = &v
+	}
+
+	// Waktu di atas HH / di bawah LL dihitung langsung per sampel, tanpa hysteresis
+	maxGap := maxSampleGap()
+	detector := &tankEventDetector{tank: name, maxGap: maxGap}
+	var aboveHH, belowLL float64
+	for i, ts := range series.Times {
+		l := limitAt(i)
+		next := ts.Add(expectedSamplePeriod)
+		if i+1 < len(series.Times) {
+			next = series.Times[i+1]
+		}
+		dt := sampleSpan(ts, next, maxGap).Seconds()
+		if l.HH != nil && levels[i] > *l.HH {
+			aboveHH += dt
+		}
+		if l.LL != nil && levels[i] < *l.LL {
+			belowLL += dt
+		}
+		detector.add(ts, levels[i], l)
+	}
+	events := detector.takeEvents()
+	if open := detector.openEvent(); open != nil {
+		events = append(events, *open)
+	}
+	var overflows, runDries int
+	for _, e := range events {
+		if e.Type == tankOverflow {
+			overflows++
+		} else {
+			runDries++
+		}
+	}
+	if events == nil {
+		events = []models.TankEvent{}
+	}
+
+	result["current_level"] = current
+	result["ll"] = currentLimit.LL
+	result["hh"] = currentLimit.HH
+	result["fill_rate_per_minute"] = fill
+	result["drain_rate_per_minute"] = drain
+	result["current_rate_per_minute"] = nil
+	if ok {
+		result["current_rate_per_minute"] = rate
+	}
+	result["minutes_to_full"] = toFull
+	result["minutes_to_empty"] = toEmpty
+	result["seconds_above_hh"] = aboveHH
+	result["seconds_below_ll"] = belowLL
+	result["overflow_count"] = overflows
+	result["run_dry_count"] = runDries
+	result["events"] = events
+	return result
+}
+
+// GetTankAnalytics -> laju isi/kuras, estimasi penuh/kosong dan event overflow/run-dry BT1, BT2, VD
+func GetTankAnalytics(c *gin.Context) {
+	startTime, endTime, err := getTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid date format. Use: YYYY-MM-DD HH:MM:SS or YYYY-MM-DDTHH:MM:SS",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if endTime.Sub(startTime) > maxTankRange {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "rentang waktu maksimal 7 hari"})
+		return
+	}
+
+	rateWindow := defaultTankRateWindow
+	if v := c.Query("rate_window"); v != "" {
+		rateWindow, err = strconv.Atoi(v)
+		if err != nil || rateWindow <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "rate_window harus bilangan bulat menit > 0"})
+			return
+		}
+	}
+
+	limits, err := getTankLimits()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil batas alarm", "error": err.Error()})
+		return
+	}
+
+	series, err := getPasteurSeries(tankSeriesFields(), startTime, endTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data tank", "error": err.Error()})
+		return
+	}
+
+	tanks := make([]gin.H, 0, len(pasteurTanks))
+	for _, t := range pasteurTanks {
+		result := analyzeTank(t.Name, t.Field, limits[t.Field], series, time.Duration(rateWindow)*time.Minute)
+		tanks = append(tanks, result)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":             true,
+		"rate_window_minutes": rateWindow,
+		"data":                tanks,
+		"filter": gin.H{
+			"start_date": startTime.Format("2006-01-02 15:04:05"),
+			"end_date":   endTime.Format("2006-01-02 15:04:05"),
+			"timezone":   "Asia/Jakarta (WIB)",
+		},
+	})
+}
+
+// GetTankEvents -> event overflow/run-dry yang tersimpan (from, to, tank, type)
+func GetTankEvents(c *gin.Context) {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	query := config.DB.Where("date BETWEEN ? AND ?", from, to).Order("start ASC")
+	if tank := c.Query("tank"); tank != "" {
+		if !isPasteurTank(tank) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "tank harus bt1, bt2 atau vd"})
+			return
+		}
+		query = query.Where("tank = ?", tank)
+	}
+	if eventType := c.Query("type"); eventType != "" {
+		if eventType != tankOverflow && eventType != tankRunDry {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "type harus overflow atau run_dry"})
+			return
+		}
+		query = query.Where("type = ?", eventType)
+	}
+
+	var events []models.TankEvent
+	if err := query.Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil event tank", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "from": from, "to": to, "count": len(events), "data": events})
+}
@@ -0,0 +1,273 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"backend-golang/config"
+	"backend-golang/models"
+)
+
+// Hysteresis dan durasi minimal supaya noise level di sekitar HH/LL tidak menjadi banyak event
+const (
+	tankHysteresis      = 1.0 // %, event ditutup setelah level kembali melewati batas sejauh ini
+	tankMinEventSeconds = 10  // event lebih pendek dari ini dibuang
+)
+
+// Interval recorder dan rentang data yang dibaca ulang saat server start
+const (
+	tankRecorderInterval = time.Minute
+	tankRecorderBackfill = 6 * time.Hour
+)
+
+// Field yang dibaca untuk analitik tank: level tiap tank + setpoint VD
+func tankSeriesFields() []string {
+	fields := make([]string, 0, len(pasteurTanks)+2)
+	for _, t := range pasteurTanks {
+		fields = append(fields, t.Field)
+	}
+	return append(fields, "vdll", "vdhh")
+}
+
+// Batas LL/HH aktif per field level. Hanya LL/HH yang dipakai untuk overflow/run-dry.
+// Batas bawaan level_bt1/level_bt2 diisi nonaktif sampai dikonfirmasi, aktifkan lewat
+// PUT /alarm-limits/:id {"active": true}; level_vd memakai setpoint VDLL/VDHH.
+func getTankLimits() (map[string]models.AlarmLimit, error) {
+	fields := make([]string, 0, len(pasteurTanks))
+	for _, t := range pasteurTanks {
+		fields = append(fields, t.Field)
+	}
+	limits, err := getActiveAlarmLimits(fields)
+	if err != nil {
+		return nil, err
+	}
+	byField := make(map[string]models.AlarmLimit, len(pasteurTanks))
+	for _, t := range pasteurTanks {
+		byField[t.Field] = models.AlarmLimit{Field: t.Field}
+	}
+	for _, l := range limits {
+		byField[l.Field] = models.AlarmLimit{Field: l.Field, LL: l.LL, HH: l.HH}
+	}
+	return byField, nil
+}
+
+// Pengelompok sampel level satu tank menjadi event overflow/run-dry dengan hysteresis.
+// Event dibuka saat level melewati HH/LL dan ditutup saat level kembali sejauh
+// tankHysteresis di dalam batas, atau saat ada jeda data lebih dari maxGap.
+type tankEventDetector struct {
+	tank        string
+	maxGap      time.Duration
+	current     *models.TankEvent
+	start, last time.Time
+	events      []models.TankEvent // event yang sudah ditutup
+}
+
+// Lengkapi waktu dan durasi event dari state detector
+func (d *tankEventDetector) snapshot(open bool) models.TankEvent {
+	e := *d.current
+	e.Start = d.start.Format(dbTimeFormat)
+	e.End = d.last.Format(dbTimeFormat)
+	e.DurationSeconds = d.last.Sub(d.start).Seconds() + expectedSamplePeriod.Seconds()
+	e.Date = d.start.Format("2006-01-02")
+	e.Ongoing = open
+	return e
+}
+
+func (d *tankEventDetector) closeEvent() {
+	if d.current == nil {
+		return
+	}
+	if e := d.snapshot(false); e.DurationSeconds >= tankMinEventSeconds {
+		d.events = append(d.events, e)
+	}
+	d.current = nil
+}
+
+func (d *tankEventDetector) add(ts time.Time, level float64, limit models.AlarmLimit) {
+	if d.current != nil && ts.Sub(d.last) > d.maxGap {
+		d.closeEvent()
+	}
+	if d.current != nil {
+		switch d.current.Type {
+		case tankOverflow:
+			if limit.HH == nil || level < *limit.HH-tankHysteresis {
+				d.closeEvent()
+			}
+		case tankRunDry:
+			if limit.LL == nil || level > *limit.LL+tankHysteresis {
+				d.closeEvent()
+			}
+		}
+	}
+
+	if d.current == nil {
+		switch {
+		case limit.HH != nil && level > *limit.HH:
+			d.current = &models.TankEvent{Tank: d.tank, Type: tankOverflow, Peak: level, Threshold: *limit.HH}
+		case limit.LL != nil && level < *limit.LL:
+			d.current = &models.TankEvent{Tank: d.tank, Type: tankRunDry, Peak: level, Threshold: *limit.LL}
+		default:
+			return
+		}
+		d.start = ts
+	}
+	d.last = ts
+	if (d.current.Type == tankOverflow && level > d.current.Peak) || (d.current.Type == tankRunDry && level < d.current.Peak) {
+		d.current.Peak = level
+	}
+}
+
+// Event yang sudah cukup panjang tapi belum ditutup
+func (d *tankEventDetector) openEvent() *models.TankEvent {
+	if d.current == nil {
+		return nil
+	}
+	e := d.snapshot(true)
+	if e.DurationSeconds < tankMinEventSeconds {
+		return nil
+	}
+	return &e
+}
+
+// Ambil dan kosongkan event yang sudah ditutup
+func (d *tankEventDetector) takeEvents() []models.TankEvent {
+	events := d.events
+	d.events = nil
+	return events
+}
+
+// Simpan satu event, event yang sama (tank + tipe + mulai) diperbarui
+func saveTankEvent(e models.TankEvent) error {
+	record := models.TankEvent{Tank: e.Tank, Type: e.Type, Start: e.Start}
+	return config.DB.Where(models.TankEvent{Tank: e.Tank, Type: e.Type, Start: e.Start}).
+		Assign(map[string]interface{}{
+			"end":              e.End,
+			"duration_seconds": e.DurationSeconds,
+			"peak":             e.Peak,
+			"threshold":        e.Threshold,
+			"date":             e.Date,
+			"ongoing":          e.Ongoing,
+		}).
+		FirstOrCreate(&record).Error
+}
+
+// Recorder event tank di background: membaca data baru tiap interval dan menyimpan
+// event overflow/run-dry, jadi pasteur_tank_events lengkap tanpa bergantung pada request.
+type tankEventRecorder struct {
+	detectors map[string]*tankEventDetector
+	lastTs    time.Time
+	skipStart string             // event yang mulai di sampel pertama backfill tidak diketahui awalnya
+	pending   []models.TankEvent // event selesai yang gagal disimpan, dicoba lagi di run berikutnya
+}
+
+func newTankEventRecorder() *tankEventRecorder {
+	r := &tankEventRecorder{detectors: make(map[string]*tankEventDetector, len(pasteurTanks))}
+	for _, t := range pasteurTanks {
+		r.detectors[t.Name] = &tankEventDetector{tank: t.Name, maxGap: maxSampleGap()}
+	}
+	return r
+}
+
+// Awal data saat recorder pertama jalan: backfill, atau awal event yang masih terbuka
+// supaya event itu terdeteksi ulang dengan waktu mulai yang sama
+func (r *tankEventRecorder) initialFrom(now time.Time) (time.Time, bool, error) {
+	from := now.Add(-tankRecorderBackfill)
+
+	// Event terbuka yang lebih lama dari maxTankRange (misal server mati lama) ditutup
+	// dengan end terakhir yang tersimpan, supaya tidak tercatat berlangsung selamanya
+	cutoff := now.Add(-maxTankRange).Format(dbTimeFormat)
+	err := config.DB.Model(&models.TankEvent{}).Where("ongoing = ? AND start < ?", true, cutoff).
+		Update("ongoing", false).Error
+	if err != nil {
+		return from, false, err
+	}
+
+	var open models.TankEvent
+	result := config.DB.Where("ongoing = ? AND start >= ?", true, cutoff).
+		Order("start ASC").Limit(1).Find(&open)
+	if result.Error != nil {
+		return from, false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		start, err := time.ParseInLocation(dbTimeFormat, open.Start, jakartaLoc)
+		if err == nil && start.Before(from) {
+			return start, true, nil
+		}
+	}
+	return from, false, nil
+}
+
+func (r *tankEventRecorder) run(now time.Time) error {
+	from := r.lastTs.Add(time.Second)
+	fromOpenEvent := true
+	if r.lastTs.IsZero() {
+		var err error
+		from, fromOpenEvent, err = r.initialFrom(now)
+		if err != nil {
+			return fmt.Errorf("event terbuka: %w", err)
+		}
+	}
+
+	limits, err := getTankLimits()
+	if err != nil {
+		return fmt.Errorf("batas alarm: %w", err)
+	}
+	series, err := getPasteurSeries(tankSeriesFields(), from, now)
+	if err != nil {
+		return fmt.Errorf("data tank: %w", err)
+	}
+	if len(series.Times) == 0 {
+		return nil
+	}
+	if r.lastTs.IsZero() && !fromOpenEvent {
+		r.skipStart = series.Times[0].Format(dbTimeFormat)
+	}
+
+	// Sampel hanya diberikan sekali ke detector; event yang gagal disimpan tetap di pending
+	var ongoing []models.TankEvent
+	for _, t := range pasteurTanks {
+		limitAt := sampleLimits(limits[t.Field], series)
+		detector := r.detectors[t.Name]
+		for i, ts := range series.Times {
+			detector.add(ts, series.Values[t.Field][i], limitAt(i))
+		}
+		r.pending = append(r.pending, detector.takeEvents()...)
+		if open := detector.openEvent(); open != nil {
+			ongoing = append(ongoing, *open)
+		}
+	}
+	r.lastTs = series.Times[len(series.Times)-1]
+
+	for len(r.pending) > 0 {
+		if e := r.pending[0]; e.Start != r.skipStart {
+			if err := saveTankEvent(e); err != nil {
+				return fmt.Errorf("simpan event %s: %w", e.Tank, err)
+			}
+		}
+		r.pending = r.pending[1:]
+	}
+	for _, e := range ongoing {
+		if e.Start == r.skipStart {
+			continue
+		}
+		if err := saveTankEvent(e); err != nil {
+			return fmt.Errorf("simpan event %s: %w", e.Tank, err)
+		}
+	}
+	return nil
+}
+
+// StartTankEventRecorder -> jalankan recorder event tank di background
+func StartTankEventRecorder() {
+	go func() {
+		recorder := newTankEventRecorder()
+		ticker := time.NewTicker(tankRecorderInterval)
+		defer ticker.Stop()
+		for {
+			if err := recorder.run(time.Now().In(jakartaLoc)); err != nil {
+				fmt.Printf("Error recording tank events: %v\n", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"backend-golang/models"
+)
+
+func TestTankEventDetector(t *testing.T) {
+	limit := models.AlarmLimit{Field: "level_bt1", LL: limitPtr(5), HH: limitPtr(90)}
+	start := time.Date(2026, 1, 5, 8, 0, 0, 0, jakartaLoc)
+
+	// Level berosilasi di sekitar HH selama n detik lalu turun jauh di bawah HH
+	noiseAroundHH := func(n int) []float64 {
+		var levels []float64
+		for i := 0; i < n; i++ {
+			if i%2 == 0 {
+				levels = append(levels, 90.4)
+			} else {
+				levels = append(levels, 89.6)
+			}
+		}
+		return append(levels, 80, 80)
+	}
+	constant := func(v float64, n int) []float64 {
+		levels := make([]float64, n)
+		for i := range levels {
+			levels[i] = v
+		}
+		return levels
+	}
+
+	tests := []struct {
+		name     string
+		levels   []float64
+		gapAfter int // jeda data 1 menit setelah sampel ke-n (0 = tanpa jeda)
+		events   int
+		typ      string
+		duration float64
+		peak     float64
+		open     bool
+	}{
+		{name: "noise di sekitar HH jadi satu event", levels: noiseAroundHH(100), events: 1, typ: tankOverflow, duration: 100, peak: 90.4},
+		{name: "event lebih pendek dari durasi minimal dibuang", levels: append(constant(92, 5), 80), events: 0},
+		{name: "run dry tertutup setelah lewat hysteresis", levels: append(append(constant(4, 20), 5.5, 4.5), constant(6.5, 3)...), events: 1, typ: tankRunDry, duration: 22, peak: 4},
+		{name: "jeda data menutup event", levels: constant(95, 40), gapAfter: 20, events: 1, typ: tankOverflow, duration: 20, peak: 95, open: true},
+		{name: "event di akhir data masih terbuka", levels: constant(95, 30), events: 0, open: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &tankEventDetector{tank: "bt1", maxGap: 10 * time.Second}
+			ts := start
+			for i, level := range tt.levels {
+				if tt.gapAfter > 0 && i == tt.gapAfter {
+					ts = ts.Add(time.Minute)
+				}
+				d.add(ts, level, limit)
+				ts = ts.Add(time.Second)
+			}
+
+			events := d.takeEvents()
+			if open := d.openEvent(); open != nil {
+				if !tt.open {
+					t.Errorf("event terbuka tidak diharapkan: %+v", *open)
+				}
+			} else if tt.open {
+				t.Error("event terbuka tidak ditemukan")
+			}
+			if len(events) != tt.events {
+				t.Fatalf("event = %d, want %d (%+v)", len(events), tt.events, events)
+			}
+			if tt.events == 0 {
+				return
+			}
+			e := events[0]
+			if e.Type != tt.typ || e.DurationSeconds != tt.duration || e.Peak != tt.peak || e.Ongoing {
+				t.Errorf("event = %s %vs peak %v ongoing %v, want %s %vs peak %v", e.Type, e.DurationSeconds, e.Peak, e.Ongoing, tt.typ, tt.duration, tt.peak)
+			}
+		})
+	}
+}
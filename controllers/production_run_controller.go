@@ -0,0 +1,494 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"backend-golang/config"
+	"backend-golang/models"
+	"backend-golang/shiftcal"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Format waktu untuk kolom string WIB (production_runs, stop_reasons)
+const dbTimeFormat = "2006-01-02 15:04:05"
+
+// Potongan waktu dalam satu shift yang menjalankan satu produk.
+// Segment tanpa run berisi Product kosong; jika berada di antara dua run dengan produk
+// berbeda maka dihitung sebagai changeover.
+type runSegment struct {
+	RunID      uint      `json:"run_id,omitempty"`
+	Product    string    `json:"product"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Changeover bool      `json:"changeover,omitempty"`
+}
+
+// Ambil run yang beririsan dengan rentang waktu, urut berdasarkan waktu mulai
+func getProductionRuns(line string, start, end time.Time) ([]models.ProductionRun, error) {
+	var runs []models.ProductionRun
+	err := config.DB.
+		Where("line = ? AND start_time <= ? AND (end_time = '' OR end_time >= ?)",
+			strings.ToLower(line), end.In(jakartaLoc).Format(dbTimeFormat), start.In(jakartaLoc).Format(dbTimeFormat)).
+		Order("start_time ASC").
+		Find(&runs).Error
+	return runs, err
+}
+
+// Bagi rentang waktu menjadi segment per run
+func splitByRuns(runs []models.ProductionRun, start, end time.Time) []runSegment {
+	var segments []runSegment
+	cursor := start
+	prevProduct, hasPrev := "", false
+
+	for _, run := range runs {
+		runStart, err := time.ParseInLocation(dbTimeFormat, run.Start, jakartaLoc)
+		if err != nil {
+			continue
+		}
+		runEnd := end
+		if run.End != "" {
+			if parsed, err := time.ParseInLocation(dbTimeFormat, run.End, jakartaLoc); err == nil && parsed.Before(end) {
+				runEnd = parsed
+			}
+		}
+		if runStart.Before(cursor) {
+			runStart = cursor
+		}
+		if !runEnd.After(runStart) {
+			continue
+		}
+
+		if runStart.After(cursor) {
+			segments = append(segments, runSegment{
+				Start:      cursor,
+				End:        runStart,
+				Changeover: hasPrev && prevProduct != run.Product,
+			})
+		}
+		segments = append(segments, runSegment{
+			RunID:   run.ID,
+			Product: run.Product,
+			Start:   runStart,
+			End:     runEnd,
+		})
+		cursor = runEnd
+		prevProduct, hasPrev = run.Product, true
+	}
+
+	if cursor.Before(end) {
+		segments = append(segments, runSegment{Start: cursor, End: end})
+	}
+	return segments
+}
+
+// Total menit changeover dalam daftar segment
+func changeoverMinutes(segments []runSegment, now time.Time) float64 {
+	total := 0.0
+	for _, seg := range segments {
+		if !seg.Changeover || now.Before(seg.Start) {
+			continue
+		}
+		end := seg.End
+		if now.Before(end) {
+			end = now
+		}
+		total += end.Sub(seg.Start).Minutes()
+	}
+	return total
+}
+
+// Output, runtime dan main speed satu segment production run
+type segmentStats struct {
+	packs      int64
+	resets     int
+	runSeconds float64
+	mainSpeed  int64 // main_speed sampel terakhir di segment
+}
+
+// Bagi sampel shift ke segment production run berdasarkan timestamp.
+// Counter diakumulasi sekali untuk seluruh shift seperti buildHourlyBuckets, jadi delta di
+// batas segment tidak hilang dan total semua segment sama dengan getShiftCounter.
+// Runtime dihitung seperti getShiftRunStop (sampleSpan, sampel terakhir sampai windowEnd).
+func splitSamplesBySegment(samples []RetailSample, segments []runSegment, windowEnd time.Time, rollover int64, maxGap time.Duration) []segmentStats {
+	stats := make([]segmentStats, len(segments))
+	acc := newCounterAccumulator(rollover)
+	idx := 0
+
+	for i, seg := range segments {
+		lastSegment := i == len(segments)-1
+		resetsBefore := acc.result.Resets
+		// Segment terakhir juga mengambil sampel tepat di akhir shift
+		for idx < len(samples) && (lastSegment || samples[idx].Ts.Before(seg.End)) {
+			sample := samples[idx]
+			stats[i].packs += acc.add(CounterRecord{Ts: sample.Ts, TotalCounter: sample.TotalCounter})
+			stats[i].mainSpeed = int64(sample.MainSpeed)
+
+			if sample.StartMesin == 1 {
+				next := windowEnd
+				if idx+1 < len(samples) {
+					next = samples[idx+1].Ts
+				}
+				stats[i].runSeconds += sampleSpan(sample.Ts, next, maxGap).Seconds()
+			}
+			idx++
+		}
+		if lastSegment {
+			// Reset di akhir shift yang belum naik lagi
+			acc.finish()
+		}
+		stats[i].resets = acc.result.Resets - resetsBefore
+	}
+	return stats
+}
+
+// Hitung performance dan good filling per produk dalam satu shift.
+// Waktu changeover tidak dihitung ke produk manapun dan dikembalikan terpisah.
+// Data shift diambil sekali lalu dibagi per segment dengan splitSamplesBySegment.
+func splitShiftByProduct(line string, s shiftcal.Shift, baseDate, now time.Time) ([]gin.H, float64, error) {
+	runs, err := getProductionRuns(line, s.Start, s.End)
+	if err != nil {
+		return nil, 0, err
+	}
+	segments := splitByRuns(runs, s.Start, s.End)
+
+	var samples []RetailSample
+	if !now.Before(s.Start) {
+		if samples, err = getRetailSamples(line, s.Start, s.End); err != nil {
+			return nil, 0, err
+		}
+	}
+	stats := splitSamplesBySegment(samples, segments, integrationEnd(s.End, now), counterRollover(line), maxSampleGap())
+
+	type productTotals struct {
+		segments                  int
+		plannedMinutes            float64
+		runSeconds                float64
+		counter                   int64
+		resets                    int
+		expected, fillingCapacity float64
+		target                    lineTarget
+	}
+	var order []string
+	totals := make(map[string]*productTotals)
+
+	// Waktu terencana per segment proporsional terhadap planned minutes shift
+	plannedRatio := 0.0
+	if d := s.End.Sub(s.Start).Minutes(); d > 0 {
+		plannedRatio = float64(s.PlannedMinutes) / d
+	}
+
+	for i, seg := range segments {
+		if seg.Changeover || now.Before(seg.Start) {
+			continue
+		}
+
+		t, ok := totals[seg.Product]
+		if !ok {
+			t = &productTotals{target: getLineTargetOrDefault(line, seg.Product, baseDate)}
+			totals[seg.Product] = t
+			order = append(order, seg.Product)
+		}
+
+		segEnd := seg.End
+		if now.Before(segEnd) {
+			segEnd = now
+		}
+		planned := segEnd.Sub(seg.Start).Minutes() * plannedRatio
+
+		st := stats[i]
+
+		t.segments++
+		t.plannedMinutes += planned
+		t.runSeconds += st.runSeconds
+		t.counter += st.packs
+		t.resets += st.resets
+		t.expected += planned * t.target.RatePerMinute()
+		t.fillingCapacity += st.runSeconds / 60 * float64(st.mainSpeed) * float64(t.target.HeadsPerMachine)
+	}
+
+	var products []gin.H
+	for _, product := range order {
+		t := totals[product]
+
+		performanceOutput := 0.0
+		if t.expected > 0 {
+			performanceOutput = float64(t.counter) / t.expected * 100
+		}
+		var goodFilling, gagalFilling float64
+		if t.fillingCapacity > 0 {
+			goodFilling = float64(t.counter) / t.fillingCapacity * 100
+			if goodFilling > 100 {
+				goodFilling = 100
+			}
+			gagalFilling = 100 - goodFilling
+		}
+
+		name := product
+		if name == "" {
+			name = "UNASSIGNED"
+		}
+		products = append(products, gin.H{
+			"product":            name,
+			"segments":           t.segments,
+			"planned_minutes":    t.plannedMinutes,
+			"runtime_minutes":    int64(t.runSeconds / 60),
+			"total_counter":      t.counter,
+			"counter_resets":     t.resets,
+			"expected_output":    int64(t.expected),
+			"performance_output": performanceOutput,
+			"good_filling":       goodFilling,
+			"gagal_filling":      gagalFilling,
+			"target":             t.target,
+		})
+	}
+
+	return products, changeoverMinutes(segments, now), nil
+}
+
+// Parse dan validasi run sebelum disimpan
+func validateProductionRun(run models.ProductionRun) error {
+	if !isRetailLine(run.Line) {
+		return fmt.Errorf("line %s tidak valid", run.Line)
+	}
+	start, err := time.ParseInLocation(dbTimeFormat, run.Start, jakartaLoc)
+	if err != nil {
+		return fmt.Errorf("start harus berformat YYYY-MM-DD HH:MM:SS")
+	}
+	if run.End != "" {
+		end, err := time.ParseInLocation(dbTimeFormat, run.End, jakartaLoc)
+		if err != nil {
+			return fmt.Errorf("end harus berformat YYYY-MM-DD HH:MM:SS")
+		}
+		if !end.After(start) {
+			return fmt.Errorf("end harus setelah start")
+		}
+	}
+	return nil
+}
+
+// Run dalam satu line tidak boleh tumpang tindih
+func productionRunOverlaps(run models.ProductionRun) (bool, error) {
+	end := run.End
+	if end == "" {
+		end = "9999-12-31 23:59:59"
+	}
+	var overlap int64
+	err := config.DB.Model(&models.ProductionRun{}).
+		Where("line = ? AND id <> ? AND start_time < ? AND (end_time = '' OR end_time > ?)", run.Line, run.ID, end, run.Start).
+		Count(&overlap).Error
+	return overlap > 0, err
+}
+
+// GetProductionRuns -> daftar run satu line dalam rentang tanggal
+func GetProductionRuns(c *gin.Context) {
+	line := strings.ToLower(c.Param("line"))
+
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	var runs []models.ProductionRun
+	err = config.DB.
+		Where("line = ? AND start_time <= ? AND (end_time = '' OR end_time >= ?)", line, to+" 23:59:59", from+" 00:00:00").
+		Order("start_time ASC").
+		Find(&runs).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil production run", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "line": line, "from": from, "to": to, "count": len(runs), "data": runs})
+}
+
+// CreateProductionRun -> operator mencatat run produk
+func CreateProductionRun(c *gin.Context) {
+	var run models.ProductionRun
+	if err := c.ShouldBindJSON(&run); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Body tidak valid", "error": err.Error()})
+		return
+	}
+	run.ID = 0
+	run.Line = strings.ToLower(c.Param("line"))
+	run.Source = "manual"
+
+	if err := validateProductionRun(run); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	overlap, err := productionRunOverlaps(run)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengecek overlap production run", "error": err.Error()})
+		return
+	}
+	if overlap {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": fmt.Sprintf("run tumpang tindih dengan run lain di line %s", run.Line)})
+		return
+	}
+
+	if err := config.DB.Create(&run).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan production run", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": run})
+}
+
+// UpdateProductionRun -> ubah produk / waktu run (misal melengkapi run hasil infer)
+func UpdateProductionRun(c *gin.Context) {
+	line := strings.ToLower(c.Param("line"))
+
+	var existing models.ProductionRun
+	if err := config.DB.Where("line = ?", line).First(&existing, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Production run tidak ditemukan"})
+		return
+	}
+
+	var run models.ProductionRun
+	if err := c.ShouldBindJSON(&run); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Body tidak valid", "error": err.Error()})
+		return
+	}
+	run.ID = existing.ID
+	run.Line = line
+	run.Source = "manual"
+	run.CreatedAt = existing.CreatedAt
+
+	if err := validateProductionRun(run); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	overlap, err := productionRunOverlaps(run)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengecek overlap production run", "error": err.Error()})
+		return
+	}
+	if overlap {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": fmt.Sprintf("run tumpang tindih dengan run lain di line %s", run.Line)})
+		return
+	}
+
+	if err := config.DB.Save(&run).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengubah production run", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": run})
+}
+
+// DeleteProductionRun -> hapus run
+func DeleteProductionRun(c *gin.Context) {
+	result := config.DB.Where("line = ?", strings.ToLower(c.Param("line"))).Delete(&models.ProductionRun{}, c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menghapus production run", "error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Production run tidak ditemukan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Production run dihapus"})
+}
+
+// InferProductionRuns -> buat run dari titik reset counter pada tanggal tertentu.
+// Periode yang sudah punya run tidak diubah dan dilaporkan di skipped beserta alasannya.
+// Produk diambil dari parameter product; jika kosong run dibiarkan tanpa produk supaya
+// operator mengisinya, bukan menebak dari run sebelumnya.
+func InferProductionRuns(c *gin.Context) {
+	line := strings.ToLower(c.Param("line"))
+	if !isRetailLine(line) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": fmt.Sprintf("Line %s tidak terdaftar", line)})
+		return
+	}
+
+	baseDate, err := parseRetailDate(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format tanggal salah. Gunakan YYYY-MM-DD"})
+		return
+	}
+
+	calendar, err := shiftcal.ForDate(line, baseDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": fmt.Sprintf("Gagal mengambil kalender shift: %v", err)})
+		return
+	}
+
+	now := time.Now().In(jakartaLoc)
+	created := []models.ProductionRun{}
+	skipped := []gin.H{}
+
+	for _, s := range calendar {
+		if now.Before(s.Start) {
+			continue
+		}
+		samples, err := getRetailSamples(line, s.Start, s.End)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": fmt.Sprintf("Gagal mengambil data: %v", err)})
+			return
+		}
+		if len(samples) == 0 {
+			continue
+		}
+
+		records := make([]CounterRecord, 0, len(samples))
+		for _, sample := range samples {
+			records = append(records, CounterRecord{Ts: sample.Ts, TotalCounter: sample.TotalCounter})
+		}
+
+		// Batas run: awal data, setiap reset counter, akhir data
+		bounds := []time.Time{samples[0].Ts}
+		for _, t := range accumulateCounter(records, counterRollover(line)).ResetTimes {
+			if t.After(bounds[len(bounds)-1]) {
+				bounds = append(bounds, t)
+			}
+		}
+		lastTs := samples[len(samples)-1].Ts
+		if lastTs.After(bounds[len(bounds)-1]) {
+			bounds = append(bounds, lastTs)
+		}
+
+		for i := 0; i+1 < len(bounds); i++ {
+			run := models.ProductionRun{
+				Line:    line,
+				Product: c.Query("product"),
+				Start:   bounds[i].Format(dbTimeFormat),
+				End:     bounds[i+1].Format(dbTimeFormat),
+				Source:  "inferred",
+				Note:    fmt.Sprintf("Infer dari reset counter shift %d", s.No),
+			}
+			if err := validateProductionRun(run); err != nil {
+				skipped = append(skipped, gin.H{"start": run.Start, "end": run.End, "reason": err.Error()})
+				continue
+			}
+			overlap, err := productionRunOverlaps(run)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengecek overlap production run", "error": err.Error()})
+				return
+			}
+			if overlap {
+				skipped = append(skipped, gin.H{"start": run.Start, "end": run.End, "reason": "sudah ada run lain pada periode ini"})
+				continue
+			}
+			if err := config.DB.Create(&run).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan production run", "error": err.Error()})
+				return
+			}
+			created = append(created, run)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"line":    line,
+		"date":    baseDate.Format("2006-01-02"),
+		"count":   len(created),
+		"data":    created,
+		"skipped": skipped,
+	})
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSplitSamplesBySegment(t *testing.T) {
+	start := time.Date(2026, 1, 5, 6, 0, 0, 0, jakartaLoc)
+	at := func(m int) time.Time { return start.Add(time.Duration(m) * time.Minute) }
+
+	// Sampel per menit, counter naik 10 per menit; reset ke 0 tepat sebelum segment B
+	var samples []RetailSample
+	counter := 1000
+	for m := 0; m <= 30; m++ {
+		if m == 20 {
+			counter = 0
+		}
+		samples = append(samples, RetailSample{Ts: at(m), StartMesin: 1, TotalCounter: counter, MainSpeed: 100 + m})
+		counter += 10
+	}
+
+	segments := []runSegment{
+		{Product: "A", Start: at(0), End: at(10)},
+		{Changeover: true, Start: at(10), End: at(15)},
+		{Product: "B", Start: at(15), End: at(30)},
+	}
+	// maxGap 2 menit supaya sampel per menit dianggap berurutan
+	stats := splitSamplesBySegment(samples, segments, at(30), 0, 2*time.Minute)
+
+	records := make([]CounterRecord, len(samples))
+	for i, s := range samples {
+		records[i] = CounterRecord{Ts: s.Ts, TotalCounter: s.TotalCounter}
+	}
+	shiftTotal := accumulateCounter(records, 0)
+
+	var packs int64
+	var resets int
+	for _, st := range stats {
+		packs += st.packs
+		resets += st.resets
+	}
+	if packs != shiftTotal.Produced || resets != shiftTotal.Resets {
+		t.Errorf("total segment = %d pack/%d reset, total shift = %d/%d", packs, resets, shiftTotal.Produced, shiftTotal.Resets)
+	}
+
+	tests := []struct {
+		name      string
+		stat      segmentStats
+		packs     int64
+		resets    int
+		runSecs   float64
+		mainSpeed int64
+	}{
+		// Delta 09:00 -> 10:00 milik sampel 10:00 yang masuk changeover
+		{name: "segment A", stat: stats[0], packs: 90, runSecs: 600, mainSpeed: 109},
+		{name: "changeover", stat: stats[1], packs: 50, runSecs: 300, mainSpeed: 114},
+		{name: "segment B dengan reset", stat: stats[2], packs: 50 + 10 + 90, resets: 1, runSecs: 900, mainSpeed: 130},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.stat.packs != tt.packs || tt.stat.resets != tt.resets || tt.stat.runSeconds != tt.runSecs || tt.stat.mainSpeed != tt.mainSpeed {
+				t.Errorf("stat = %+v, want packs %d resets %d run %v speed %d", tt.stat, tt.packs, tt.resets, tt.runSecs, tt.mainSpeed)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"backend-golang/config"
+	"backend-golang/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Target bawaan jika belum ada di tabel production_targets: 40 pack/menit per head, 2 head per mesin
+const (
+	defaultRatedSpeed      = 40
+	defaultHeadsPerMachine = 2
+)
+
+// Target yang berlaku untuk satu line/produk pada satu tanggal
+type lineTarget struct {
+	RatedSpeed      float64 `json:"rated_speed"`
+	HeadsPerMachine int     `json:"heads_per_machine"`
+	Product         string  `json:"product"`
+	EffectiveDate   string  `json:"effective_date,omitempty"`
+	Source          string  `json:"source"` // table, line atau default
+}
+
+// Output ideal per menit mesin jalan (rated speed x jumlah head)
+func (t lineTarget) RatePerMinute() float64 {
+	return t.RatedSpeed * float64(t.HeadsPerMachine)
+}
+
+// Ambil target yang berlaku: target produk > target default line > rated registry line > target bawaan
+func getLineTarget(line, product string, date time.Time) (lineTarget, error) {
+	var target models.ProductionTarget
+	result := config.DB.
+		Where("line = ? AND (product = ? OR product = '') AND effective_date <= ?",
+			strings.ToLower(line), product, date.Format("2006-01-02")).
+		Order("product = '' ASC, effective_date DESC").
+		Limit(1).
+		Find(&target)
+	if result.Error != nil {
+		return lineTarget{}, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return defaultLineTarget(line, product), nil
+	}
+
+	return lineTarget{
+		RatedSpeed:      target.RatedSpeed,
+		HeadsPerMachine: target.HeadsPerMachine,
+		Product:         target.Product,
+		EffectiveDate:   target.EffectiveDate,
+		Source:          "table",
+	}, nil
+}
+
+// Versi getLineTarget yang fallback ke target bawaan jika DB error
+func getLineTargetOrDefault(line, product string, date time.Time) lineTarget {
+	target, err := getLineTarget(line, product, date)
+	if err != nil {
+		fmt.Printf("Error getting target for %s: %v\n", line, err)
+		return defaultLineTarget(line, product)
+	}
+	return target
+}
+
+// Target dari parameter rated di registry line, atau target bawaan jika belum diisi
+func defaultLineTarget(line, product string) lineTarget {
+	if l, ok := getRetailLine(line); ok && l.RatedSpeed > 0 && l.HeadsPerMachine > 0 {
+		return lineTarget{RatedSpeed: l.RatedSpeed, HeadsPerMachine: l.HeadsPerMachine, Product: product, Source: "line"}
+	}
+	return lineTarget{RatedSpeed: defaultRatedSpeed, HeadsPerMachine: defaultHeadsPerMachine, Product: product, Source: "default"}
+}
+
+// Validasi isi target sebelum disimpan
+func validateProductionTarget(t models.ProductionTarget) error {
+	if !isRetailLine(t.Line) {
+		return fmt.Errorf("line %s tidak valid", t.Line)
+	}
+	if _, err := time.Parse("2006-01-02", t.EffectiveDate); err != nil {
+		return fmt.Errorf("effective_date harus berformat YYYY-MM-DD")
+	}
+	if t.RatedSpeed <= 0 {
+		return fmt.Errorf("rated_speed harus lebih dari 0")
+	}
+	if t.HeadsPerMachine <= 0 {
+		return fmt.Errorf("heads_per_machine harus lebih dari 0")
+	}
+	return nil
+}
+
+// GetProductionTargets -> daftar target, bisa filter line dan product
+func GetProductionTargets(c *gin.Context) {
+	var targets []models.ProductionTarget
+
+	query := config.DB.Order("line ASC, product ASC, effective_date DESC")
+	if line := c.Query("line"); line != "" {
+		query = query.Where("line = ?", strings.ToLower(line))
+	}
+	if product, ok := c.GetQuery("product"); ok {
+		query = query.Where("product = ?", product)
+	}
+
+	if err := query.Find(&targets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil target", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "count": len(targets), "data": targets})
+}
+
+// CreateProductionTarget -> tambah target baru
+func CreateProductionTarget(c *gin.Context) {
+	var target models.ProductionTarget
+	if err := c.ShouldBindJSON(&target); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Body tidak valid", "error": err.Error()})
+		return
+	}
+	target.ID = 0
+	target.Line = strings.ToLower(target.Line)
+
+	if err := validateProductionTarget(target); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	if err := config.DB.Create(&target).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan target", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": target})
+}
+
+// UpdateProductionTarget -> ubah target berdasarkan id
+func UpdateProductionTarget(c *gin.Context) {
+	var existing models.ProductionTarget
+	if err := config.DB.First(&existing, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Target tidak ditemukan"})
+		return
+	}
+
+	var target models.ProductionTarget
+	if err := c.ShouldBindJSON(&target); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Body tidak valid", "error": err.Error()})
+		return
+	}
+	target.ID = existing.ID
+	target.CreatedAt = existing.CreatedAt
+	target.Line = strings.ToLower(target.Line)
+
+	if err := validateProductionTarget(target); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	if err := config.DB.Save(&target).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengubah target", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": target})
+}
+
+// DeleteProductionTarget -> hapus target
+func DeleteProductionTarget(c *gin.Context) {
+	result := config.DB.Delete(&models.ProductionTarget{}, c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menghapus target", "error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Target tidak ditemukan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Target dihapus"})
+}
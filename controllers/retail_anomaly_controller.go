@@ -0,0 +1,257 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"backend-golang/shiftcal"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Jenis anomali data PLC retail
+const (
+	anomalyCounterBackwards = "counter_backwards"
+	anomalyFrozenCounter    = "frozen_counter"
+	anomalyImpossibleSpeed  = "impossible_speed"
+)
+
+// Tingkat keparahan anomali
+const (
+	severityWarning  = "warning"
+	severityCritical = "critical"
+)
+
+// Batas bawaan scanner, bisa diganti lewat query parameter
+const (
+	defaultFrozenSeconds  = 60  // counter tidak naik selama mesin jalan
+	criticalCounterDrop   = 100 // penurunan counter (pack) yang dianggap critical
+	criticalSpeedSeconds  = 10  // speed mustahil lebih lama dari ini dianggap critical
+	maxSpeedRatedMultiple = 2   // main_speed di atas 2x rated speed dianggap mustahil
+)
+
+// Satu temuan anomali dalam data retail
+type retailAnomaly struct {
+	Type            string    `json:"type"`
+	Severity        string    `json:"severity"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Value           float64   `json:"value"` // besar penurunan, nilai counter beku, atau speed terburuk
+	Message         string    `json:"message"`
+}
+
+// Counter turun tanpa reset/rollover, memakai klasifikasi yang sama dengan accumulateCounter
+func scanCounterBackwards(samples []RetailSample, rollover int64) []retailAnomaly {
+	records := make([]CounterRecord, 0, len(samples))
+	for _, s := range samples {
+		records = append(records, CounterRecord{Ts: s.Ts, TotalCounter: s.TotalCounter})
+	}
+
+	var anomalies []retailAnomaly
+	for _, d := range accumulateCounter(records, rollover).Drops {
+		drop := d.From - d.To
+		severity := severityWarning
+		if drop > criticalCounterDrop {
+			severity = severityCritical
+		}
+		message := fmt.Sprintf("total_counter turun dari %d ke %d tanpa reset", d.From, d.To)
+		switch d.Resolution {
+		case dropRecovered:
+			message += ", lalu kembali naik"
+		case dropOpen:
+			message += ", belum pulih sampai akhir data"
+		default:
+			message += fmt.Sprintf(", berakhir dengan %s", d.Resolution)
+		}
+		anomalies = append(anomalies, retailAnomaly{
+			Type:            anomalyCounterBackwards,
+			Severity:        severity,
+			Start:           d.Start,
+			End:             d.End,
+			DurationSeconds: d.End.Sub(d.Start).Seconds(),
+			Value:           float64(drop),
+			Message:         message,
+		})
+	}
+	return anomalies
+}
+
+// Counter tidak berubah padahal start_mesin = 1 dan main_speed > 0
+func scanFrozenCounter(samples []RetailSample, minSeconds float64) []retailAnomaly {
+	var anomalies []retailAnomaly
+	flush := func(from, to int) {
+		duration := samples[to].Ts.Sub(samples[from].Ts).Seconds()
+		if duration < minSeconds {
+			return
+		}
+		severity := severityWarning
+		if duration >= minSeconds*5 {
+			severity = severityCritical
+		}
+		anomalies = append(anomalies, retailAnomaly{
+			Type:            anomalyFrozenCounter,
+			Severity:        severity,
+			Start:           samples[from].Ts,
+			End:             samples[to].Ts,
+			DurationSeconds: duration,
+			Value:           float64(samples[from].TotalCounter),
+			Message:         fmt.Sprintf("total_counter tetap %d selama %.0f detik saat mesin jalan", samples[from].TotalCounter, duration),
+		})
+	}
+
+	start := -1
+	for i := 1; i < len(samples); i++ {
+		frozen := samples[i].StartMesin == 1 && samples[i].MainSpeed > 0 &&
+			samples[i].TotalCounter == samples[i-1].TotalCounter
+		if frozen {
+			if start < 0 {
+				start = i - 1
+			}
+			continue
+		}
+		if start >= 0 {
+			flush(start, i-1)
+			start = -1
+		}
+	}
+	if start >= 0 {
+		flush(start, len(samples)-1)
+	}
+	return anomalies
+}
+
+// main_speed negatif atau di atas batas maksimal, sampel berurutan digabung jadi satu temuan
+func scanImpossibleSpeed(samples []RetailSample, maxSpeed float64) []retailAnomaly {
+	var anomalies []retailAnomaly
+	impossible := func(speed int) bool {
+		return speed < 0 || float64(speed) > maxSpeed
+	}
+
+	for i := 0; i < len(samples); i++ {
+		if !impossible(samples[i].MainSpeed) {
+			continue
+		}
+		j, worst := i, samples[i].MainSpeed
+		for j+1 < len(samples) && impossible(samples[j+1].MainSpeed) {
+			j++
+			if samples[j].MainSpeed < 0 || samples[j].MainSpeed > worst {
+				worst = samples[j].MainSpeed
+			}
+		}
+
+		duration := samples[j].Ts.Sub(samples[i].Ts).Seconds() + 1
+		severity := severityWarning
+		if worst < 0 || duration > criticalSpeedSeconds {
+			severity = severityCritical
+		}
+		anomalies = append(anomalies, retailAnomaly{
+			Type:            anomalyImpossibleSpeed,
+			Severity:        severity,
+			Start:           samples[i].Ts,
+			End:             samples[j].Ts,
+			DurationSeconds: duration,
+			Value:           float64(worst),
+			Message:         fmt.Sprintf("main_speed %d di luar rentang 0-%.0f", worst, maxSpeed),
+		})
+		i = j
+	}
+	return anomalies
+}
+
+// Jalankan semua scanner pada data satu shift, hasil urut berdasarkan waktu
+func scanRetailAnomalies(samples []RetailSample, frozenSeconds, maxSpeed float64, rollover int64) []retailAnomaly {
+	anomalies := scanCounterBackwards(samples, rollover)
+	anomalies = append(anomalies, scanFrozenCounter(samples, frozenSeconds)...)
+	anomalies = append(anomalies, scanImpossibleSpeed(samples, maxSpeed)...)
+	sort.SliceStable(anomalies, func(i, j int) bool {
+		return anomalies[i].Start.Before(anomalies[j].Start)
+	})
+	return anomalies
+}
+
+// Controller untuk anomaly scanner data retail per shift
+func RetailAnomalies(c *gin.Context) {
+	line := c.Param("line")
+
+	// Validasi line
+	if !isRetailLine(line) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Line %s tidak terdaftar", line)})
+		return
+	}
+
+	baseDate, err := parseRetailDate(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal salah. Gunakan YYYY-MM-DD"})
+		return
+	}
+
+	frozenSeconds := float64(defaultFrozenSeconds)
+	if v := c.Query("frozen_seconds"); v != "" {
+		frozenSeconds, err = strconv.ParseFloat(v, 64)
+		if err != nil || frozenSeconds <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "frozen_seconds harus angka lebih dari 0"})
+			return
+		}
+	}
+
+	target := getLineTargetOrDefault(line, "", baseDate)
+	maxSpeed := target.RatedSpeed * maxSpeedRatedMultiple
+	if v := c.Query("max_speed"); v != "" {
+		maxSpeed, err = strconv.ParseFloat(v, 64)
+		if err != nil || maxSpeed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_speed harus angka lebih dari 0"})
+			return
+		}
+	}
+
+	calendar, err := shiftcal.ForDate(line, baseDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal mengambil kalender shift: %v", err)})
+		return
+	}
+	selected, err := filterShifts(calendar, c.Query("shift"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now().In(jakartaLoc)
+	shifts := []gin.H{}
+	summary := map[string]int{severityWarning: 0, severityCritical: 0}
+
+	for _, s := range selected {
+		var anomalies []retailAnomaly
+		if !now.Before(s.Start) {
+			samples, err := getRetailSamples(line, s.Start, s.End)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal mengambil data: %v", err)})
+				return
+			}
+			anomalies = scanRetailAnomalies(samples, frozenSeconds, maxSpeed, counterRollover(line))
+		}
+
+		for _, a := range anomalies {
+			summary[a.Severity]++
+		}
+		shifts = append(shifts, gin.H{
+			"shift":      s.No,
+			"start_time": s.Start,
+			"end_time":   s.End,
+			"count":      len(anomalies),
+			"anomalies":  anomalies,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"line":           line,
+		"date":           baseDate.Format("2006-01-02"),
+		"frozen_seconds": frozenSeconds,
+		"max_speed":      maxSpeed,
+		"summary":        summary,
+		"shifts":         shifts,
+	})
+}
@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"backend-golang/shiftcal"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrik yang dibandingkan antar line; HigherBetter menentukan arah ranking
+var compareMetrics = []struct {
+	Key          string
+	HigherBetter bool
+}{
+	{"uptime", true},
+	{"performance_output", true},
+	{"good_filling", true},
+	{"stop_count", false},
+	{"total_counter", true},
+}
+
+// Jumlah stop (selesai maupun masih berjalan) satu line dalam rentang tanggal
+func countStops(line string, from, to, now time.Time) (int, error) {
+	shifts, err := shiftcal.ForRange(line, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("kalender shift: %w", err)
+	}
+
+	total := 0
+	for _, s := range shifts {
+		events, err := getShiftStopEvents(line, s, now)
+		if err != nil {
+			return 0, fmt.Errorf("stop shift %d %s: %w", s.No, s.Date, err)
+		}
+		total += len(events)
+	}
+	return total, nil
+}
+
+// Ringkasan metrik pembanding satu line
+func compareLineMetrics(line string, from, to, now time.Time) (gin.H, error) {
+	_, total, err := calculateLineKPI(line, from, to, "day", now)
+	if err != nil {
+		return nil, err
+	}
+	stops, err := countStops(line, from, to, now)
+	if err != nil {
+		return nil, err
+	}
+
+	kpi := total.toResponse()
+	return gin.H{
+		"uptime":             kpi["uptime"],
+		"performance_output": kpi["performance_output"],
+		"good_filling":       kpi["good_filling"],
+		"stop_count":         float64(stops),
+		"total_counter":      float64(total.TotalCounter),
+	}, nil
+}
+
+// Beri ranking per metrik (1 = terbaik). Nilai sama mendapat ranking sama.
+func rankCompareLines(lines []gin.H) {
+	for _, m := range compareMetrics {
+		var idx []int
+		for i, l := range lines {
+			if l["success"] == true {
+				idx = append(idx, i)
+			}
+		}
+
+		value := func(i int) float64 {
+			return lines[i]["metrics"].(gin.H)[m.Key].(float64)
+		}
+		sort.SliceStable(idx, func(a, b int) bool {
+			if m.HigherBetter {
+				return value(idx[a]) > value(idx[b])
+			}
+			return value(idx[a]) < value(idx[b])
+		})
+
+		for pos, i := range idx {
+			rank := pos + 1
+			if pos > 0 && value(i) == value(idx[pos-1]) {
+				rank = lines[idx[pos-1]]["ranks"].(gin.H)[m.Key].(int)
+			}
+			lines[i]["ranks"].(gin.H)[m.Key] = rank
+		}
+	}
+}
+
+// Controller untuk membandingkan beberapa line dalam rentang tanggal yang sama
+func RetailCompare(c *gin.Context) {
+	lines := activeRetailLines()
+	if param := c.Query("lines"); param != "" {
+		lines = nil
+		for _, l := range strings.Split(param, ",") {
+			l = strings.ToLower(strings.TrimSpace(l))
+			if l == "" {
+				continue
+			}
+			if !isRetailLine(l) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Line %s tidak terdaftar", l)})
+				return
+			}
+			lines = append(lines, l)
+		}
+	}
+	if len(lines) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Minimal satu line untuk dibandingkan"})
+		return
+	}
+
+	from, to, err := parseDateRangeDates(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	withDelta := c.Query("delta") == "last_week"
+	prevFrom, prevTo := from.AddDate(0, 0, -7), to.AddDate(0, 0, -7)
+
+	now := time.Now().In(jakartaLoc)
+	results := runPerLine(lines, overviewWorkers, func(line string) (gin.H, error) {
+		metrics, err := compareLineMetrics(line, from, to, now)
+		if err != nil {
+			return nil, err
+		}
+		result := gin.H{"line": line, "metrics": metrics, "ranks": gin.H{}}
+
+		// Selisih terhadap periode yang sama minggu lalu
+		if withDelta {
+			previous, err := compareLineMetrics(line, prevFrom, prevTo, now)
+			if err != nil {
+				return nil, fmt.Errorf("minggu lalu: %w", err)
+			}
+			delta := gin.H{}
+			for _, m := range compareMetrics {
+				delta[m.Key] = metrics[m.Key].(float64) - previous[m.Key].(float64)
+			}
+			result["previous"] = previous
+			result["delta"] = delta
+		}
+		return result, nil
+	})
+
+	rankCompareLines(results)
+
+	response := gin.H{
+		"from":  from.Format("2006-01-02"),
+		"to":    to.Format("2006-01-02"),
+		"lines": results,
+	}
+	if withDelta {
+		response["previous_from"] = prevFrom.Format("2006-01-02")
+		response["previous_to"] = prevTo.Format("2006-01-02")
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -9,227 +9,46 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"backend-golang/config"
-	"backend-golang/models"
+	"backend-golang/shiftcal"
 )
 
-// Helper function untuk mendapatkan model berdasarkan line
-func getModelByLine(line string) interface{} {
-	switch strings.ToLower(line) {
-	case "d1":
-		return &models.RetailD1{}
-	case "d2":
-		return &models.RetailD2{}
-	case "d3":
-		return &models.RetailD3{}
-	case "d4":
-		return &models.RetailD4{}
-	case "d5":
-		return &models.RetailD5{}
-	case "d6":
-		return &models.RetailD6{}
-	case "d7":
-		return &models.RetailD7{}
-	case "d8":
-		return &models.RetailD8{}
-	case "d9":
-		return &models.RetailD9{}
-	case "d10":
-		return &models.RetailD10{}
-	case "d14":
-		return &models.RetailD14{}
-	default:
-		return nil
-	}
-}
-
 // Helper function untuk mendapatkan table name berdasarkan line
 func getTableByLine(line string) string {
-	return fmt.Sprintf("retail_%s", strings.ToLower(line))
-}
-
-// Ambil total runtime (start_mesin = 1) dari DB
-func getShiftRuntime(line string, start, end, now time.Time) int64 {
-	// Jika shift belum dimulai, return 0
-	if now.Before(start) {
-		return 0
-	}
-	
-	// Konversi ke Asia/Jakarta dulu, lalu format tanpa timezone (seperti di DB)
-	loc, _ := time.LoadLocation("Asia/Jakarta")
-	startLocal := start.In(loc)
-	endLocal := end.In(loc)
-	
-	// Format ke string tanpa timezone (format yang sama dengan DB)
-	startStr := startLocal.Format("2006-01-02 15:04:05")
-	endStr := endLocal.Format("2006-01-02 15:04:05")
-	
-	// Debug: print query parameters
-	fmt.Printf("Query DB Runtime %s - Start: %s, End: %s\n", line, startStr, endStr)
-	
-	model := getModelByLine(line)
-	if model == nil {
-		fmt.Printf("Invalid line: %s\n", line)
-		return 0
+	if l, ok := getRetailLine(line); ok {
+		return l.DataTable
 	}
-
-	var countSeconds int64
-	result := config.DB.Model(model).
-		Where("start_mesin = ? AND ts >= ? AND ts <= ?", 1, startStr, endStr).
-		Count(&countSeconds)
-
-	if result.Error != nil {
-		fmt.Printf("DB Error for %s: %v\n", line, result.Error)
-		return 0
-	}
-
-	fmt.Printf("DB Result Runtime %s - Count: %d seconds (%d minutes)\n", line, countSeconds, countSeconds/60)
-	
-	return countSeconds / 60 // convert detik → menit
+	return fmt.Sprintf("retail_%s", strings.ToLower(line))
 }
 
-// Ambil total stoptime (start_mesin = 0) dari DB
-func getShiftStoptime(line string, start, end, now time.Time) int64 {
+// Ambil total runtime (start_mesin = 1) dan stoptime (start_mesin = 0) dalam menit,
+// dihitung dari selisih timestamp dengan satu query getShiftRunStop
+func getShiftRunStopMinutes(line string, start, end, now time.Time) (runtime, stoptime int64) {
 	// Jika shift belum dimulai, return 0
 	if now.Before(start) {
-		return 0
-	}
-	
-	// Konversi ke Asia/Jakarta dulu, lalu format tanpa timezone (seperti di DB)
-	loc, _ := time.LoadLocation("Asia/Jakarta")
-	startLocal := start.In(loc)
-	endLocal := end.In(loc)
-	
-	// Format ke string tanpa timezone (format yang sama dengan DB)
-	startStr := startLocal.Format("2006-01-02 15:04:05")
-	endStr := endLocal.Format("2006-01-02 15:04:05")
-	
-	// Debug: print query parameters
-	fmt.Printf("Query DB Stoptime %s - Start: %s, End: %s\n", line, startStr, endStr)
-	
-	model := getModelByLine(line)
-	if model == nil {
-		fmt.Printf("Invalid line: %s\n", line)
-		return 0
-	}
-
-	var countSeconds int64
-	result := config.DB.Model(model).
-		Where("start_mesin = ? AND ts >= ? AND ts <= ?", 0, startStr, endStr).
-		Count(&countSeconds)
-
-	if result.Error != nil {
-		fmt.Printf("DB Error for %s: %v\n", line, result.Error)
-		return 0
-	}
-
-	fmt.Printf("DB Result Stoptime %s - Count: %d seconds (%d minutes)\n", line, countSeconds, countSeconds/60)
-	
-	return countSeconds / 60 // convert detik → menit
-}
-
-func getActualShiftMinutes(start, end, now time.Time) int64 {
-	loc, _ := time.LoadLocation("Asia/Jakarta")
-	start = start.In(loc)
-	end = end.In(loc)
-	now = now.In(loc)
-
-	var actualMinutes int64
-	if now.Before(start) {
-		actualMinutes = 0
-	} else if now.After(end) {
-		actualMinutes = int64(end.Sub(start).Minutes())
-	} else {
-		actualMinutes = int64(now.Sub(start).Minutes())
-	}
-
-	// Tentukan batas maksimal per hari
-	weekday := start.Weekday() // time.Weekday (0=Sunday, 6=Saturday)
-	var maxMinutes int64
-	if weekday == time.Saturday {
-		maxMinutes = 300 // Sabtu = 5 jam kerja
-	} else {
-		maxMinutes = 420 // default = 7 jam kerja
+		return 0, 0
 	}
 
-	// Batasi
-	if actualMinutes > maxMinutes {
-		actualMinutes = maxMinutes
+	duration, err := getShiftRunStop(line, start, end, now)
+	if err != nil {
+		fmt.Printf("DB Error for %s: %v\n", line, err)
+		return 0, 0
 	}
 
-	return actualMinutes
+	return int64(duration.Run.Minutes()), int64(duration.Stop.Minutes())
 }
 
-// Tentukan range shift (pakai timezone dari baseDate)
-func getShiftRange(baseDate time.Time, shift int) (time.Time, time.Time) {
-	loc := baseDate.Location()
-	weekday := baseDate.Weekday()
-	
-	// Cek apakah hari Sabtu
-	isSaturday := weekday == time.Saturday
-	
-	if isSaturday {
-		// Shift khusus untuk Sabtu (5 jam per shift)
-		switch shift {
-		case 1:
-			start := time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day(), 6, 0, 0, 0, loc)
-			end := time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day(), 11, 0, 0, 0, loc)
-			return start, end
-		case 2:
-			start := time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day(), 11, 0, 1, 0, loc)
-			end := time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day(), 16, 0, 0, 0, loc)
-			return start, end
-		case 3:
-			start := time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day(), 16, 0, 1, 0, loc)
-			end := time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day(), 21, 0, 0, 0, loc)
-			return start, end
-		}
-	} else {
-		// Shift normal untuk hari biasa (7-8 jam per shift)
-		switch shift {
-		case 1:
-			start := time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day(), 6, 0, 0, 0, loc)
-			end := time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day(), 14, 0, 0, 0, loc)
-			return start, end
-		case 2:
-			start := time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day(), 14, 1, 0, 0, loc)
-			end := time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day(), 22, 0, 0, 0, loc)
-			return start, end
-		case 3:
-			start := time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day(), 22, 1, 0, 0, loc)
-			end := time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day()+1, 5, 59, 59, 0, loc)
-			return start, end
-		}
-	}
-	
-	return baseDate, baseDate
-}
+// Shift default jika kalender tidak bisa dibaca atau tidak ada shift, sama dengan fallback lama (shift 3)
+const fallbackCurrentShift = 3
 
-// Tentukan shift sekarang (disesuaikan untuk Sabtu)
-func getCurrentShift(now time.Time) int {
-	hour, min := now.Hour(), now.Minute()
-	weekday := now.Weekday()
-	
-	if weekday == time.Saturday {
-		// Shift khusus untuk Sabtu
-		if hour >= 6 && (hour < 11 || (hour == 11 && min == 0)) {
-			return 1
-		} else if (hour > 11 || (hour == 11 && min >= 1)) && (hour < 16 || (hour == 16 && min == 0)) {
-			return 2
-		} else if (hour > 16 || (hour == 16 && min >= 1)) && hour < 21 {
-			return 3
-		}
-	} else {
-		// Shift normal untuk hari biasa
-		if hour >= 6 && (hour < 14 || (hour == 14 && min == 0)) {
-			return 1
-		} else if (hour > 14 || (hour == 14 && min >= 1)) && hour < 22 {
-			return 2
-		} else {
-			return 3
-		}
+// Tentukan nomor shift yang sedang berjalan dari kalender shift.
+// Di luar jam shift (jeda antar shift, malam Sabtu, libur) dipakai shift terakhir yang
+// sudah selesai, seperti perilaku lama yang mengembalikan shift 3 di luar jam kerja.
+func getCurrentShift(line string, now time.Time) int {
+	shift, ok, err := shiftcal.Latest(line, now)
+	if err != nil || !ok {
+		return fallbackCurrentShift
 	}
-	
-	return 3
+	return shift.No
 }
 
 // Controller untuk Uptime (Runtime)
@@ -238,8 +57,8 @@ func UptimeStartMesinRealtime(c *gin.Context) {
 	dateParam := c.Query("date")
 
 	// Validasi line
-	if getModelByLine(line) == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Line %s tidak valid. Gunakan d1-d14", line)})
+	if !isRetailLine(line) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Line %s tidak terdaftar", line)})
 		return
 	}
 
@@ -266,16 +85,22 @@ func UptimeStartMesinRealtime(c *gin.Context) {
 	// Debug: print current time
 	fmt.Printf("Current time (Asia/Jakarta): %v for line %s\n", now, line)
 	
-	var shifts []gin.H
+	shifts := []gin.H{}
 
-	for i := 1; i <= 3; i++ {
-		start, end := getShiftRange(baseDate, i)
+	calendar, err := shiftcal.ForDate(line, baseDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal mengambil kalender shift: %v", err)})
+		return
+	}
+
+	for _, s := range calendar {
+		i, start, end := s.No, s.Start, s.End
 		
 		// Debug: print shift times
 		fmt.Printf("Shift %d for %s: Start=%v, End=%v\n", i, line, start, end)
 
-		runtimeMinutes := getShiftRuntime(line, start, end, now)
-		actualMinutes := getActualShiftMinutes(start, end, now)
+		runtimeMinutes, _ := getShiftRunStopMinutes(line, start, end, now)
+		actualMinutes := s.ActualMinutes(now)
 		
 		// Debug: print calculations
 		fmt.Printf("Shift %d for %s: Runtime=%d, Actual=%d\n", i, line, runtimeMinutes, actualMinutes)
@@ -298,7 +123,7 @@ func UptimeStartMesinRealtime(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"line":          line,
 		"date":          baseDate.Format("2006-01-02"),
-		"current_shift": getCurrentShift(now),
+		"current_shift": getCurrentShift(line, now),
 		"shifts":        shifts,
 	})
 }
@@ -309,8 +134,8 @@ func DowntimeStopMesinRealtime(c *gin.Context) {
 	dateParam := c.Query("date")
 
 	// Validasi line
-	if getModelByLine(line) == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Line %s tidak valid. Gunakan d1-d14", line)})
+	if !isRetailLine(line) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Line %s tidak terdaftar", line)})
 		return
 	}
 
@@ -337,16 +162,22 @@ func DowntimeStopMesinRealtime(c *gin.Context) {
 	// Debug: print current time
 	fmt.Printf("Current time (Asia/Jakarta): %v for line %s\n", now, line)
 	
-	var shifts []gin.H
+	shifts := []gin.H{}
+
+	calendar, err := shiftcal.ForDate(line, baseDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal mengambil kalender shift: %v", err)})
+		return
+	}
 
-	for i := 1; i <= 3; i++ {
-		start, end := getShiftRange(baseDate, i)
+	for _, s := range calendar {
+		i, start, end := s.No, s.Start, s.End
 		
 		// Debug: print shift times
 		fmt.Printf("Shift %d for %s: Start=%v, End=%v\n", i, line, start, end)
 
-		downtimeMinutes := getShiftStoptime(line, start, end, now)
-		actualMinutes := getActualShiftMinutes(start, end, now)
+		_, downtimeMinutes := getShiftRunStopMinutes(line, start, end, now)
+		actualMinutes := s.ActualMinutes(now)
 		
 		// Debug: print calculations
 		fmt.Printf("Shift %d for %s: Downtime=%d, Actual=%d\n", i, line, downtimeMinutes, actualMinutes)
@@ -369,31 +200,64 @@ func DowntimeStopMesinRealtime(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"line":          line,
 		"date":          baseDate.Format("2006-01-02"),
-		"current_shift": getCurrentShift(now),
+		"current_shift": getCurrentShift(line, now),
 		"shifts":        shifts,
 	})
 }
 
-// Optimized getLatestTotalCounter (1 query saja)
 // Type definition untuk counter record
 type CounterRecord struct {
 	Ts           time.Time `json:"ts"`
 	TotalCounter int       `json:"total_counter"`
 }
 
-// Optimized getLatestTotalCounter dengan logika yang diperbaiki
-func getLatestTotalCounter(line string, start, end, now time.Time) int64 {
+// Jumlah sampel berurutan yang konsisten naik sebelum counter yang mundur dianggap
+// baseline baru (misal nilai counter PLC dipulihkan ke nilai lama)
+const counterRebaselineSamples = 10
+
+// Hasil akumulasi counter dalam satu rentang waktu
+type CounterResult struct {
+	Produced     int64         `json:"produced"`
+	Resets       int           `json:"resets"`
+	Rollovers    int           `json:"rollovers"`
+	ZeroGlitches int           `json:"zero_glitches"`
+	Backwards    int           `json:"backwards"`
+	ResetTimes   []time.Time   `json:"-"` // waktu terjadinya reset, dipakai untuk infer production run
+	Drops        []counterDrop `json:"-"` // ekskursi data mundur tanpa reset, dipakai anomaly scanner
+}
+
+// Cara ekskursi counter mundur berakhir
+const (
+	dropRecovered  = "recovered" // counter kembali ke nilai sebelum turun
+	dropReset      = "reset"
+	dropRollover   = "rollover"
+	dropRebaseline = "rebaseline" // nilai baru konsisten, dipakai sebagai baseline
+	dropOpen       = "open"       // belum pulih sampai akhir data
+)
+
+// Satu ekskursi counter turun tanpa reset maupun rollover: dibuka pada sampel mundur
+// pertama dan ditutup saat counter pulih, reset atau rollover
+type counterDrop struct {
+	Start      time.Time
+	End        time.Time
+	From       int64 // nilai terakhir sebelum turun
+	To         int64 // nilai terendah selama ekskursi
+	Samples    int
+	Resolution string
+}
+
+// Ambil total_counter dalam shift lalu jumlahkan delta-nya, error DB dikembalikan ke pemanggil
+func getShiftCounter(line string, start, end, now time.Time) (CounterResult, error) {
 	if now.Before(start) {
-		return 0
+		return CounterResult{}, nil
 	}
 
 	loc, _ := time.LoadLocation("Asia/Jakarta")
 	startStr := start.In(loc).Format("2006-01-02 15:04:05")
 	endStr := end.In(loc).Format("2006-01-02 15:04:05")
 
-	model := getModelByLine(line)
-	if model == nil {
-		return 0
+	if !isRetailLine(line) {
+		return CounterResult{}, fmt.Errorf("line %s tidak valid", line)
 	}
 
 	// Menggunakan raw SQL query
@@ -404,107 +268,146 @@ func getLatestTotalCounter(line string, start, end, now time.Time) int64 {
 	
 	result := config.DB.Raw(query, startStr, endStr).Scan(&records)
 
-	if result.Error != nil || len(records) == 0 {
-		fmt.Printf("Error or no records for %s: %v\n", line, result.Error)
-		return 0
+	if result.Error != nil {
+		return CounterResult{}, result.Error
 	}
 
-	// Cek apakah kita mendekati akhir shift (1 jam terakhir)
-	// Hitung durasi shift yang sudah berlalu
-	shiftDuration := end.Sub(start)
-	elapsed := now.Sub(start)
-	isNearEndShift := elapsed >= (shiftDuration - time.Hour) // 1 jam sebelum akhir shift
-	
-	fmt.Printf("Shift analysis for %s: Duration=%v, Elapsed=%v, NearEnd=%v\n", 
-		line, shiftDuration, elapsed, isNearEndShift)
+	return accumulateCounter(records, counterRollover(line)), nil
+}
 
-	if isNearEndShift {
-		// LOGIKA MENDEKATI AKHIR SHIFT: Ambil data sebelum 0
-		return getCounterBeforeZero(records, line)
-	} else {
-		// LOGIKA AWAL SHIFT: Prioritaskan counter yang mulai dari awal shift
-		return getCounterForEarlyShift(records, line)
-	}
+// Akumulator total_counter per sampel.
+// - naik: tambah selisihnya
+// - turun ke dekat 0 dari dekat batas PLC: rollover, tambah sisa sampai batas + nilai baru
+//   (hanya jika batas rollover line dikonfigurasi di registry)
+// - turun (langsung atau lewat 0): reset, counter mulai dari 0 sehingga nilai baru ditambahkan
+// - 0 sesaat lalu kembali ke nilai semula atau lebih: glitch PLC, tidak dihitung reset
+// - turun sedikit tanpa reset: data mundur, diabaikan dan dicatat sebagai satu ekskursi.
+//   Jika counter naik konsisten selama counterRebaselineSamples sampel dari nilai yang
+//   mundur, nilai itu dipakai sebagai baseline baru dan kenaikannya dihitung.
+type counterAccumulator struct {
+	rollover      int64 // 0 = tanpa deteksi rollover
+	result        CounterResult
+	prev          int64
+	pendingZero   bool
+	pendingZeroAt time.Time
+	drop          *counterDrop // ekskursi mundur yang sedang terbuka
+	runStart      int64        // nilai awal deret naik konsisten selama ekskursi
+	runLast       int64
+	runLen        int
 }
 
-// Helper function untuk mendapat counter sebelum nilai 0 (untuk akhir shift)
-func getCounterBeforeZero(records []CounterRecord, line string) int64 {
-	var lastBeforeZero int64 = 0
-	
-	for i := 0; i < len(records); i++ {
-		current := records[i].TotalCounter
-		
-		if current > 0 {
-			lastBeforeZero = int64(current)
-			
-			// Cek apakah data berikutnya adalah 0
-			if i+1 < len(records) && records[i+1].TotalCounter == 0 {
-				// Ini adalah data terakhir sebelum 0, gunakan ini
-				fmt.Printf("Near end shift - Found data before zero for %s: %d at %v\n", 
-					line, lastBeforeZero, records[i].Ts)
-				return lastBeforeZero
-			}
-		}
+func newCounterAccumulator(rollover int64) *counterAccumulator {
+	return &counterAccumulator{rollover: rollover, prev: -1}
+}
+
+func (a *counterAccumulator) closeDrop(ts time.Time, resolution string) {
+	if a.drop == nil {
+		return
 	}
-	
-	// Jika tidak ada pola "sebelum 0", ambil yang terakhir > 0
-	fmt.Printf("Near end shift - Using last non-zero for %s: %d\n", line, lastBeforeZero)
-	return lastBeforeZero
+	a.drop.End = ts
+	a.drop.Resolution = resolution
+	a.result.Drops = append(a.result.Drops, *a.drop)
+	a.drop = nil
 }
 
-// Helper function untuk mendapat counter di awal shift
-func getCounterForEarlyShift(records []CounterRecord, line string) int64 {
-	// Cari pola reset: dari nilai besar ke 0, lalu naik lagi
-	var resetIndex = -1
-	var maxValueBeforeReset int64 = 0
-	
-	// Cari titik reset (dari nilai tinggi ke 0)
-	for i := 0; i < len(records)-1; i++ {
-		current := records[i].TotalCounter
-		next := records[i+1].TotalCounter
-		
-		if current > 0 && next == 0 {
-			maxValueBeforeReset = int64(current)
-			resetIndex = i + 1 // index dimana nilai menjadi 0
-			break
-		}
+// Proses satu sampel, kembalikan jumlah pack yang dikreditkan pada sampel ini
+func (a *counterAccumulator) add(r CounterRecord) int64 {
+	value := int64(r.TotalCounter)
+	if value < 0 {
+		return 0
 	}
-	
-	if resetIndex != -1 {
-		// Ada reset, cari nilai terakhir setelah reset
-		for i := resetIndex; i < len(records); i++ {
-			if records[i].TotalCounter > 0 {
-				// Cek apakah ini nilai yang masuk akal untuk shift baru
-				currentValue := int64(records[i].TotalCounter)
-				if currentValue < maxValueBeforeReset {
-					// Nilai ini lebih kecil dari sebelum reset, kemungkinan counter baru
-					// Cari nilai terakhir dari periode ini
-					var lastInNewPeriod int64 = currentValue
-					for j := i + 1; j < len(records); j++ {
-						if records[j].TotalCounter > 0 {
-							lastInNewPeriod = int64(records[j].TotalCounter)
-						}
-					}
-					fmt.Printf("Early shift - Found counter after reset for %s: %d (was %d before reset)\n", 
-						line, lastInNewPeriod, maxValueBeforeReset)
-					return lastInNewPeriod
-				}
-			}
+	if a.prev < 0 {
+		a.prev = value
+		return 0
+	}
+
+	if value == 0 && a.prev > 0 {
+		// Belum bisa dipastikan reset atau glitch, tunggu data berikutnya
+		if !a.pendingZero {
+			a.pendingZeroAt = r.Ts
 		}
+		a.pendingZero = true
+		return 0
 	}
-	
-	// Tidak ada reset yang jelas, ambil nilai terakhir > 0
-	var lastNonZero int64 = 0
-	for i := len(records) - 1; i >= 0; i-- {
-		if records[i].TotalCounter > 0 {
-			lastNonZero = int64(records[i].TotalCounter)
-			fmt.Printf("Early shift - Found last non-zero for %s: %d at %v\n", 
-				line, lastNonZero, records[i].Ts)
-			break
+
+	var delta int64
+	switch {
+	case a.pendingZero && value >= a.prev:
+		a.result.ZeroGlitches++
+		a.closeDrop(r.Ts, dropRecovered)
+		delta = value - a.prev
+	case a.pendingZero:
+		a.result.Resets++
+		a.result.ResetTimes = append(a.result.ResetTimes, a.pendingZeroAt)
+		a.closeDrop(a.pendingZeroAt, dropReset)
+		delta = value
+	case value >= a.prev:
+		a.closeDrop(r.Ts, dropRecovered)
+		delta = value - a.prev
+	case a.rollover > 0 && a.prev >= a.rollover*9/10 && value <= a.rollover/10:
+		a.result.Rollovers++
+		a.closeDrop(r.Ts, dropRollover)
+		delta = a.rollover - a.prev + value + 1
+	case value <= a.prev/2:
+		// Reset tanpa sempat terbaca 0
+		a.result.Resets++
+		a.result.ResetTimes = append(a.result.ResetTimes, r.Ts)
+		a.closeDrop(r.Ts, dropReset)
+		delta = value
+	default:
+		// Data mundur: satu ekskursi sampai counter pulih
+		if a.drop == nil {
+			a.result.Backwards++
+			a.drop = &counterDrop{Start: r.Ts, From: a.prev, To: value}
+			a.runLen = 0
+		}
+		a.drop.End = r.Ts
+		a.drop.Samples++
+		if value < a.drop.To {
+			a.drop.To = value
+		}
+
+		// Lacak deret tidak turun; jika cukup panjang dan sudah naik, jadikan baseline baru
+		if a.runLen == 0 || value < a.runLast {
+			a.runStart, a.runLen = value, 0
 		}
+		a.runLast = value
+		a.runLen++
+		if a.runLen < counterRebaselineSamples || value == a.runStart {
+			return 0
+		}
+		a.closeDrop(r.Ts, dropRebaseline)
+		delta = value - a.runStart
 	}
-	
-	return lastNonZero
+	a.pendingZero = false
+
+	a.result.Produced += delta
+	a.prev = value
+	return delta
+}
+
+// Tutup state yang masih terbuka di akhir rentang
+func (a *counterAccumulator) finish() CounterResult {
+	if a.pendingZero {
+		// Counter di-reset di akhir rentang dan belum naik lagi
+		a.result.Resets++
+		a.result.ResetTimes = append(a.result.ResetTimes, a.pendingZeroAt)
+		a.closeDrop(a.pendingZeroAt, dropReset)
+		a.pendingZero = false
+	}
+	if a.drop != nil {
+		a.closeDrop(a.drop.End, dropOpen)
+	}
+	return a.result
+}
+
+// Jumlahkan kenaikan total_counter antar data berurutan
+func accumulateCounter(records []CounterRecord, rollover int64) CounterResult {
+	acc := newCounterAccumulator(rollover)
+	for _, r := range records {
+		acc.add(r)
+	}
+	return acc.finish()
 }
 
 // Controller untuk Performance Output (optimized)
@@ -513,8 +416,8 @@ func PerformanceOutput(c *gin.Context) {
 	dateParam := c.Query("date")
 
 	// Validasi line
-	if getModelByLine(line) == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Line %s tidak valid. Gunakan d1-d14", line)})
+	if !isRetailLine(line) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Line %s tidak terdaftar", line)})
 		return
 	}
 
@@ -531,19 +434,33 @@ func PerformanceOutput(c *gin.Context) {
 	}
 
 	now := time.Now().In(loc)
-	var shifts []gin.H
+	target := getLineTargetOrDefault(line, "", baseDate)
+	splitByProduct := c.Query("split") == "product"
+	shifts := []gin.H{}
+
+	calendar, err := shiftcal.ForDate(line, baseDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal mengambil kalender shift: %v", err)})
+		return
+	}
 
-	for i := 1; i <= 3; i++ {
-		start, end := getShiftRange(baseDate, i)
+	for _, s := range calendar {
+		i, start, end := s.No, s.Start, s.End
 
-		totalCounter := getLatestTotalCounter(line, start, end, now)
-		actualMinutes := getActualShiftMinutes(start, end, now)
+		counter, err := getShiftCounter(line, start, end, now)
+		if err != nil {
+			fmt.Printf("Error getting counter for %s: %v\n", line, err)
+		}
+		totalCounter := counter.Produced
+		actualMinutes := s.ActualMinutes(now)
 
 		expectedOutput := int64(0)
 		performanceOutput := 0.0
 		if actualMinutes > 0 {
-			expectedOutput = actualMinutes * 40 * 2
-			performanceOutput = float64(totalCounter) / float64(expectedOutput) * 100
+			expectedOutput = int64(float64(actualMinutes) * target.RatePerMinute())
+			if expectedOutput > 0 {
+				performanceOutput = float64(totalCounter) / float64(expectedOutput) * 100
+			}
 		}
 
 		shifts = append(shifts, gin.H{
@@ -551,25 +468,49 @@ func PerformanceOutput(c *gin.Context) {
 			"start_time":           start,
 			"end_time":             end,
 			"total_counter":        totalCounter,
+			"counter_resets":       counter.Resets,
 			"actual_shift_minutes": actualMinutes,
 			"expected_output":      expectedOutput,
 			"performance_output":   performanceOutput,
+			"data_quality":         retailDataQuality(line, start, end, now),
 		})
+
+		// Rincian per produk berdasarkan production run
+		if splitByProduct {
+			products, changeover, err := splitShiftByProduct(line, s, baseDate, now)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal mengambil production run: %v", err)})
+				return
+			}
+			shifts[len(shifts)-1]["products"] = products
+			shifts[len(shifts)-1]["changeover_minutes"] = changeover
+		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"line":          line,
 		"date":          baseDate.Format("2006-01-02"),
-		"current_shift": getCurrentShift(now),
+		"current_shift": getCurrentShift(line, now),
+		"target":        target,
 		"shifts":        shifts,
 	})
 }
 
 // Ambil main_speed terakhir dalam shift
 func getLastMainSpeed(line string, start, end, now time.Time) int64 {
-	if now.Before(start) {
+	speed, err := getShiftMainSpeed(line, start, end, now)
+	if err != nil {
+		fmt.Printf("Error getting main speed for %s: %v\n", line, err)
 		return 0
 	}
+	return speed
+}
+
+// Versi getLastMainSpeed yang mengembalikan error DB ke pemanggil
+func getShiftMainSpeed(line string, start, end, now time.Time) (int64, error) {
+	if now.Before(start) {
+		return 0, nil
+	}
 
 	loc, _ := time.LoadLocation("Asia/Jakarta")
 	startStr := start.In(loc).Format("2006-01-02 15:04:05")
@@ -584,11 +525,10 @@ func getLastMainSpeed(line string, start, end, now time.Time) int64 {
 	result := config.DB.Raw(query, startStr, endStr).Scan(&record)
 
 	if result.Error != nil {
-		fmt.Printf("Error getting main speed for %s: %v\n", line, result.Error)
-		return 0
+		return 0, result.Error
 	}
 
-	return int64(record.MainSpeed)
+	return int64(record.MainSpeed), nil
 }
 
 // Controller untuk Output Gagal Filling
@@ -597,8 +537,8 @@ func OutputGagalFilling(c *gin.Context) {
 	dateParam := c.Query("date")
 
 	// Validasi line
-	if getModelByLine(line) == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Line %s tidak valid. Gunakan d1-d14", line)})
+	if !isRetailLine(line) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Line %s tidak terdaftar", line)})
 		return
 	}
 
@@ -615,18 +555,30 @@ func OutputGagalFilling(c *gin.Context) {
 	}
 
 	now := time.Now().In(loc)
-	var shifts []gin.H
+	target := getLineTargetOrDefault(line, "", baseDate)
+	splitByProduct := c.Query("split") == "product"
+	shifts := []gin.H{}
 
-	for i := 1; i <= 3; i++ {
-		start, end := getShiftRange(baseDate, i)
+	calendar, err := shiftcal.ForDate(line, baseDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal mengambil kalender shift: %v", err)})
+		return
+	}
 
-		totalCounter := getLatestTotalCounter(line, start, end, now)
-		runtimeMinutes := getShiftRuntime(line, start, end, now) // akumulasi start_mesin = 1 dalam menit
+	for _, s := range calendar {
+		i, start, end := s.No, s.Start, s.End
+
+		counter, err := getShiftCounter(line, start, end, now)
+		if err != nil {
+			fmt.Printf("Error getting counter for %s: %v\n", line, err)
+		}
+		totalCounter := counter.Produced
+		runtimeMinutes, _ := getShiftRunStopMinutes(line, start, end, now) // akumulasi start_mesin = 1 dalam menit
 		mainSpeed := getLastMainSpeed(line, start, end, now)
 
 		var goodFilling, gagalFilling float64
 		if runtimeMinutes > 0 && mainSpeed > 0 {
-			denom := float64(runtimeMinutes) * float64(mainSpeed) * 2
+			denom := float64(runtimeMinutes) * float64(mainSpeed) * float64(target.HeadsPerMachine)
 			goodFilling = (float64(totalCounter) / denom) * 100
 			if goodFilling > 100 {
 				goodFilling = 100 // jangan lebih dari 100%
@@ -639,17 +591,31 @@ func OutputGagalFilling(c *gin.Context) {
 			"start_time":      start,
 			"end_time":        end,
 			"total_counter":   totalCounter,
+			"counter_resets":  counter.Resets,
 			"runtime_minutes": runtimeMinutes,
 			"main_speed":      mainSpeed,
 			"good_filling":    goodFilling,
 			"gagal_filling":   gagalFilling,
+			"data_quality":    retailDataQuality(line, start, end, now),
 		})
+
+		// Rincian per produk berdasarkan production run
+		if splitByProduct {
+			products, changeover, err := splitShiftByProduct(line, s, baseDate, now)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal mengambil production run: %v", err)})
+				return
+			}
+			shifts[len(shifts)-1]["products"] = products
+			shifts[len(shifts)-1]["changeover_minutes"] = changeover
+		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"date":          baseDate.Format("2006-01-02"),
 		"line":          line,
-		"current_shift": getCurrentShift(now),
+		"current_shift": getCurrentShift(line, now),
+		"target":        target,
 		"shifts":        shifts,
 	})
 }
\ No newline at end of file
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+)
+
+// Bentuk data counter per detik dari deret nilai total_counter
+func counterRecords(values ...int) []CounterRecord {
+	base := time.Date(2026, 1, 1, 6, 0, 0, 0, time.UTC)
+	records := make([]CounterRecord, 0, len(values))
+	for i, v := range values {
+		records = append(records, CounterRecord{Ts: base.Add(time.Duration(i) * time.Second), TotalCounter: v})
+	}
+	return records
+}
+
+// Deret naik satu per satu dari from sampai to
+func counterRamp(from, to int) []int {
+	var values []int
+	for v := from; v <= to; v++ {
+		values = append(values, v)
+	}
+	return values
+}
+
+func TestAccumulateCounter(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     []int
+		rollover   int64
+		produced   int64
+		resets     int
+		rollovers  int
+		glitches   int
+		backwards  int
+		resolution string // resolution ekskursi mundur pertama
+	}{
+		{name: "naik normal", values: []int{100, 105, 110}, produced: 10},
+		{name: "reset ganda", values: []int{500, 0, 20, 40, 0, 0, 15}, produced: 55, resets: 2},
+		{name: "reset tanpa terbaca nol", values: []int{500, 520, 10, 30}, produced: 50, resets: 1},
+		{name: "reset di akhir rentang", values: []int{100, 110, 0}, produced: 10, resets: 1},
+		{name: "glitch nol", values: []int{500, 0, 505}, produced: 5, glitches: 1},
+		{name: "glitch nol beberapa sampel", values: []int{500, 0, 0, 500, 502}, produced: 2, glitches: 1},
+		{name: "rollover dikonfigurasi", values: []int{65530, 65535, 3}, rollover: 65535, produced: 9, rollovers: 1},
+		{name: "rollover tidak dikonfigurasi dianggap reset", values: []int{65530, 65535, 3}, produced: 8, resets: 1},
+		{
+			name:       "mundur lalu pulih",
+			values:     []int{1000, 1010, 990, 995, 1000, 1005, 1010, 1015},
+			produced:   15,
+			backwards:  1,
+			resolution: dropRecovered,
+		},
+		{
+			name:       "mundur permanen jadi baseline baru",
+			values:     append([]int{1000, 1010}, counterRamp(900, 911)...),
+			produced:   21, // 10 sebelum mundur + 9 saat baseline baru + 2 sesudahnya
+			backwards:  1,
+			resolution: dropRebaseline,
+		},
+		{
+			name:       "mundur tetap tidak jadi baseline",
+			values:     []int{1000, 1010, 990, 990, 990, 990, 990, 990, 990, 990, 990, 990, 990, 990, 1012},
+			produced:   12,
+			backwards:  1,
+			resolution: dropRecovered,
+		},
+		{
+			name:       "mundur lalu reset",
+			values:     []int{1000, 1010, 990, 0, 25},
+			produced:   35,
+			resets:     1,
+			backwards:  1,
+			resolution: dropReset,
+		},
+		{
+			name:       "mundur belum pulih",
+			values:     []int{1000, 1010, 990, 992},
+			produced:   10,
+			backwards:  1,
+			resolution: dropOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := accumulateCounter(counterRecords(tt.values...), tt.rollover)
+			if got.Produced != tt.produced {
+				t.Errorf("produced = %d, want %d", got.Produced, tt.produced)
+			}
+			if got.Resets != tt.resets {
+				t.Errorf("resets = %d, want %d", got.Resets, tt.resets)
+			}
+			if got.Rollovers != tt.rollovers {
+				t.Errorf("rollovers = %d, want %d", got.Rollovers, tt.rollovers)
+			}
+			if got.ZeroGlitches != tt.glitches {
+				t.Errorf("zero glitches = %d, want %d", got.ZeroGlitches, tt.glitches)
+			}
+			if got.Backwards != tt.backwards || len(got.Drops) != tt.backwards {
+				t.Fatalf("backwards = %d (drops %d), want %d", got.Backwards, len(got.Drops), tt.backwards)
+			}
+			if tt.backwards > 0 && got.Drops[0].Resolution != tt.resolution {
+				t.Errorf("resolution = %s, want %s", got.Drops[0].Resolution, tt.resolution)
+			}
+		})
+	}
+}
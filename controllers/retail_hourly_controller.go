@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"backend-golang/shiftcal"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Ringkasan output satu jam dalam shift
+type hourlyBucket struct {
+	Start            time.Time `json:"start"`
+	End              time.Time `json:"end"`
+	Packs            int64     `json:"packs"`
+	CounterResets    int       `json:"counter_resets"`
+	RunningMinutes   float64   `json:"running_minutes"`
+	AvgMainSpeed     float64   `json:"avg_main_speed"`
+	HourlyTarget     float64   `json:"hourly_target"`
+	AchievementRatio float64   `json:"achievement"` // persen dari target
+}
+
+// Bagi data shift menjadi bucket per jam (jam penuh mengikuti awal shift).
+// Counter diakumulasi sekali untuk seluruh shift lalu delta per sampel dijumlahkan per jam,
+// jadi total semua jam sama dengan total shift dari getShiftCounter.
+// Menit jalan dihitung seperti getShiftRunStop: jeda data di atas maxSampleGap tidak dihitung
+// dan sampel terakhir berlaku sampai akhir shift (atau now jika shift masih berjalan).
+func buildHourlyBuckets(samples []RetailSample, s shiftcal.Shift, ratedPerMinute float64, rollover int64, now time.Time) []hourlyBucket {
+	var buckets []hourlyBucket
+	acc := newCounterAccumulator(rollover)
+	idx := 0
+	gap := maxSampleGap()
+	windowEnd := integrationEnd(s.End, now)
+
+	for start := s.Start; start.Before(s.End); start = start.Add(time.Hour) {
+		end := start.Add(time.Hour)
+		if end.After(s.End) {
+			end = s.End
+		}
+		lastBucket := !end.Before(s.End)
+
+		var packs int64
+		resetsBefore := acc.result.Resets
+		var runSeconds, speedWeighted float64
+		// Jam terakhir juga mengambil sampel tepat di akhir shift
+		for idx < len(samples) && (lastBucket || samples[idx].Ts.Before(end)) {
+			sample := samples[idx]
+			packs += acc.add(CounterRecord{Ts: sample.Ts, TotalCounter: sample.TotalCounter})
+
+			if sample.StartMesin == 1 {
+				next := windowEnd
+				if idx+1 < len(samples) {
+					next = samples[idx+1].Ts
+				}
+				dt := sampleSpan(sample.Ts, next, gap).Seconds()
+				runSeconds += dt
+				speedWeighted += float64(sample.MainSpeed) * dt
+			}
+			idx++
+		}
+		if lastBucket {
+			// Reset di akhir shift yang belum naik lagi
+			acc.finish()
+		}
+
+		target := ratedPerMinute * end.Sub(start).Minutes()
+		bucket := hourlyBucket{
+			Start:          start,
+			End:            end,
+			Packs:          packs,
+			CounterResets:  acc.result.Resets - resetsBefore,
+			RunningMinutes: runSeconds / 60,
+			HourlyTarget:   target,
+		}
+		if runSeconds > 0 {
+			bucket.AvgMainSpeed = speedWeighted / runSeconds
+		}
+		if target > 0 {
+			bucket.AchievementRatio = float64(packs) / target * 100
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets
+}
+
+// Controller untuk output per jam dalam shift
+func RetailHourlyOutput(c *gin.Context) {
+	line := c.Param("line")
+
+	// Validasi line
+	if !isRetailLine(line) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Line %s tidak terdaftar", line)})
+		return
+	}
+
+	baseDate, err := parseRetailDate(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal salah. Gunakan YYYY-MM-DD"})
+		return
+	}
+
+	calendar, err := shiftcal.ForDate(line, baseDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal mengambil kalender shift: %v", err)})
+		return
+	}
+	selected, err := filterShifts(calendar, c.Query("shift"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now().In(jakartaLoc)
+	target := getLineTargetOrDefault(line, "", baseDate)
+	shifts := []gin.H{}
+
+	for _, s := range selected {
+		var hours []hourlyBucket
+		if !now.Before(s.Start) {
+			samples, err := getRetailSamples(line, s.Start, s.End)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal mengambil data: %v", err)})
+				return
+			}
+			hours = buildHourlyBuckets(samples, s, target.RatePerMinute(), counterRollover(line), now)
+		}
+
+		var totalPacks int64
+		for _, h := range hours {
+			totalPacks += h.Packs
+		}
+
+		shifts = append(shifts, gin.H{
+			"shift":       s.No,
+			"start_time":  s.Start,
+			"end_time":    s.End,
+			"total_packs": totalPacks,
+			"hours":       hours,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"line":          line,
+		"date":          baseDate.Format("2006-01-02"),
+		"current_shift": getCurrentShift(line, now),
+		"target":        target,
+		"shifts":        shifts,
+	})
+}
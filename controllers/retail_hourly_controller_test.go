@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"backend-golang/shiftcal"
+)
+
+func TestBuildHourlyBucketsMatchesShiftTotal(t *testing.T) {
+	start := time.Date(2026, 1, 1, 6, 0, 0, 0, time.UTC)
+	shift := shiftcal.Shift{No: 1, Start: start, End: start.Add(2 * time.Hour)}
+
+	// Glitch nol tepat di sampel terakhir jam pertama, lalu counter lanjut di jam kedua
+	samples := []RetailSample{
+		{Ts: start, TotalCounter: 100},
+		{Ts: start.Add(30 * time.Minute), TotalCounter: 600},
+		{Ts: start.Add(time.Hour - time.Second), TotalCounter: 0},
+		{Ts: start.Add(time.Hour), TotalCounter: 1000},
+		{Ts: start.Add(90 * time.Minute), TotalCounter: 1200},
+		{Ts: shift.End, TotalCounter: 1500},
+	}
+
+	records := make([]CounterRecord, 0, len(samples))
+	for _, s := range samples {
+		records = append(records, CounterRecord{Ts: s.Ts, TotalCounter: s.TotalCounter})
+	}
+	want := accumulateCounter(records, 0).Produced
+
+	buckets := buildHourlyBuckets(samples, shift, 0, 0, shift.End)
+	if len(buckets) != 2 {
+		t.Fatalf("buckets = %d, want 2", len(buckets))
+	}
+	var total int64
+	for _, b := range buckets {
+		total += b.Packs
+	}
+	if total != want {
+		t.Errorf("total per jam = %d, want total shift %d", total, want)
+	}
+	if buckets[0].Packs != 500 || buckets[1].Packs != 900 {
+		t.Errorf("packs per jam = %d, %d, want 500, 900", buckets[0].Packs, buckets[1].Packs)
+	}
+}
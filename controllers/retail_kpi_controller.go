@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"backend-golang/shiftcal"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Akumulasi KPI retail untuk satu periode (shift, hari, minggu, bulan, atau total rentang)
+type kpiTotals struct {
+	Shifts          int
+	RuntimeMinutes  int64
+	DowntimeMinutes int64
+	ActualMinutes   int64
+	TotalCounter    int64
+	CounterResets   int
+	ExpectedOutput  float64
+	FillingCapacity float64
+	ExpectedSamples int64
+	ActualSamples   int64
+}
+
+func (t *kpiTotals) add(o kpiTotals) {
+	t.Shifts += o.Shifts
+	t.RuntimeMinutes += o.RuntimeMinutes
+	t.DowntimeMinutes += o.DowntimeMinutes
+	t.ActualMinutes += o.ActualMinutes
+	t.TotalCounter += o.TotalCounter
+	t.CounterResets += o.CounterResets
+	t.ExpectedOutput += o.ExpectedOutput
+	t.FillingCapacity += o.FillingCapacity
+	t.ExpectedSamples += o.ExpectedSamples
+	t.ActualSamples += o.ActualSamples
+}
+
+// Rasio KPI dihitung dari total menit/pack, bukan rata-rata persen per shift
+func (t kpiTotals) toResponse() gin.H {
+	var uptime, downtime, performance, goodFilling, gagalFilling float64
+	if t.ActualMinutes > 0 {
+		uptime = float64(t.RuntimeMinutes) / float64(t.ActualMinutes) * 100
+		downtime = float64(t.DowntimeMinutes) / float64(t.ActualMinutes) * 100
+	}
+	if t.ExpectedOutput > 0 {
+		performance = float64(t.TotalCounter) / t.ExpectedOutput * 100
+	}
+	// Tanpa kapasitas (tidak produksi, libur) good dan gagal filling tetap 0
+	if t.FillingCapacity > 0 {
+		goodFilling = float64(t.TotalCounter) / t.FillingCapacity * 100
+		if goodFilling > 100 {
+			goodFilling = 100
+		}
+		gagalFilling = 100 - goodFilling
+	}
+
+	return gin.H{
+		"shifts":                 t.Shifts,
+		"runtime_total_minutes":  t.RuntimeMinutes,
+		"downtime_total_minutes": t.DowntimeMinutes,
+		"actual_shift_minutes":   t.ActualMinutes,
+		"total_counter":          t.TotalCounter,
+		"counter_resets":         t.CounterResets,
+		"expected_output":        int64(t.ExpectedOutput),
+		"uptime":                 uptime,
+		"downtime":               downtime,
+		"performance_output":     performance,
+		"good_filling":           goodFilling,
+		"gagal_filling":          gagalFilling,
+		"data_quality":           dataQuality(t.ExpectedSamples, t.ActualSamples),
+	}
+}
+
+// Hitung KPI satu shift
+func shiftKPI(line string, s shiftcal.Shift, target lineTarget, now time.Time) (kpiTotals, error) {
+	if now.Before(s.Start) {
+		return kpiTotals{}, nil
+	}
+
+	runStop, err := getShiftRunStop(line, s.Start, s.End, now)
+	if err != nil {
+		return kpiTotals{}, fmt.Errorf("runtime: %w", err)
+	}
+	counter, err := getShiftCounter(line, s.Start, s.End, now)
+	if err != nil {
+		return kpiTotals{}, fmt.Errorf("counter: %w", err)
+	}
+	mainSpeed, err := getShiftMainSpeed(line, s.Start, s.End, now)
+	if err != nil {
+		return kpiTotals{}, fmt.Errorf("main speed: %w", err)
+	}
+
+	runtimeMinutes := int64(runStop.Run.Minutes())
+	actualMinutes := s.ActualMinutes(now)
+
+	return kpiTotals{
+		Shifts:          1,
+		RuntimeMinutes:  runtimeMinutes,
+		DowntimeMinutes: int64(runStop.Stop.Minutes()),
+		ActualMinutes:   actualMinutes,
+		TotalCounter:    counter.Produced,
+		CounterResets:   counter.Resets,
+		ExpectedOutput:  float64(actualMinutes) * target.RatePerMinute(),
+		FillingCapacity: float64(runtimeMinutes) * float64(mainSpeed) * float64(target.HeadsPerMachine),
+		ExpectedSamples: expectedSamples(s.Start, s.End, now),
+		ActualSamples:   runStop.Samples,
+	}, nil
+}
+
+// Kunci dan rentang tanggal periode untuk pengelompokan day, week (ISO) atau month
+func kpiPeriod(date time.Time, group string) (string, time.Time, time.Time) {
+	switch group {
+	case "week":
+		year, week := date.ISOWeek()
+		offset := (int(date.Weekday()) + 6) % 7 // Senin = 0
+		start := date.AddDate(0, 0, -offset)
+		return fmt.Sprintf("%d-W%02d", year, week), start, start.AddDate(0, 0, 6)
+	case "month":
+		start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+		return date.Format("2006-01"), start, start.AddDate(0, 1, -1)
+	default:
+		return date.Format("2006-01-02"), date, date
+	}
+}
+
+// Hitung KPI satu line dalam rentang tanggal, dikelompokkan per periode
+func calculateLineKPI(line string, from, to time.Time, group string, now time.Time) (gin.H, kpiTotals, error) {
+	shifts, err := shiftcal.ForRange(line, from, to)
+	if err != nil {
+		return nil, kpiTotals{}, fmt.Errorf("kalender shift: %w", err)
+	}
+
+	var order []string
+	periods := make(map[string]*kpiTotals)
+	periodRange := make(map[string][2]time.Time)
+	targets := make(map[string]lineTarget)
+	var total kpiTotals
+
+	// Semua periode dalam rentang dibuat dulu supaya hari tanpa shift tetap punya titik (nol)
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		key, start, end := kpiPeriod(d, group)
+		if _, ok := periods[key]; !ok {
+			periods[key] = &kpiTotals{}
+			periodRange[key] = [2]time.Time{start, end}
+			order = append(order, key)
+		}
+	}
+
+	for _, s := range shifts {
+		date, err := time.ParseInLocation("2006-01-02", s.Date, jakartaLoc)
+		if err != nil {
+			return nil, kpiTotals{}, fmt.Errorf("tanggal shift %q: %w", s.Date, err)
+		}
+
+		// Target bisa berubah di tengah rentang, jadi diambil per tanggal
+		target, ok := targets[s.Date]
+		if !ok {
+			target = getLineTargetOrDefault(line, "", date)
+			targets[s.Date] = target
+		}
+
+		kpi, err := shiftKPI(line, s, target, now)
+		if err != nil {
+			return nil, kpiTotals{}, fmt.Errorf("shift %d %s: %w", s.No, s.Date, err)
+		}
+
+		key, start, end := kpiPeriod(date, group)
+		p, ok := periods[key]
+		if !ok {
+			p = &kpiTotals{}
+			periods[key] = p
+			periodRange[key] = [2]time.Time{start, end}
+			order = append(order, key)
+		}
+		p.add(kpi)
+		total.add(kpi)
+	}
+
+	series := make([]gin.H, 0, len(order))
+	for _, key := range order {
+		item := periods[key].toResponse()
+		item["period"] = key
+		item["start_date"] = periodRange[key][0].Format("2006-01-02")
+		item["end_date"] = periodRange[key][1].Format("2006-01-02")
+		series = append(series, item)
+	}
+
+	return gin.H{
+		"line":    line,
+		"from":    from.Format("2006-01-02"),
+		"to":      to.Format("2006-01-02"),
+		"group":   group,
+		"periods": series,
+		"total":   total.toResponse(),
+	}, total, nil
+}
+
+// Controller untuk KPI retail multi hari (group=day|week|month)
+func RetailKPI(c *gin.Context) {
+	line := c.Param("line")
+
+	// Validasi line
+	if !isRetailLine(line) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Line %s tidak terdaftar", line)})
+		return
+	}
+
+	group := c.DefaultQuery("group", "day")
+	if group != "day" && group != "week" && group != "month" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group harus day, week atau month"})
+		return
+	}
+
+	from, to, err := parseDateRangeDates(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, _, err := calculateLineKPI(line, from, to, group, time.Now().In(jakartaLoc))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal menghitung KPI: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
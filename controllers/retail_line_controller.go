@@ -0,0 +1,238 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"backend-golang/config"
+	"backend-golang/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Registry line di-cache supaya tidak query tabel retail_lines di setiap helper
+const lineRegistryTTL = time.Minute
+
+var lineRegistry struct {
+	sync.RWMutex
+	lines    []models.RetailLine
+	loadedAt time.Time
+}
+
+// Nama line dan tabel dipakai langsung di SQL, jadi dibatasi huruf, angka dan underscore
+var identifierPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// Ambil seluruh registry line (aktif dan non-aktif), urut sesuai sort_order
+func getRetailLines() []models.RetailLine {
+	lineRegistry.RLock()
+	if !lineRegistry.loadedAt.IsZero() && time.Since(lineRegistry.loadedAt) < lineRegistryTTL {
+		lines := lineRegistry.lines
+		lineRegistry.RUnlock()
+		return lines
+	}
+	lineRegistry.RUnlock()
+
+	lineRegistry.Lock()
+	defer lineRegistry.Unlock()
+
+	// Goroutine lain mungkin sudah memuat ulang selagi menunggu lock
+	if !lineRegistry.loadedAt.IsZero() && time.Since(lineRegistry.loadedAt) < lineRegistryTTL {
+		return lineRegistry.lines
+	}
+
+	var lines []models.RetailLine
+	if err := config.DB.Order("sort_order ASC, line ASC").Find(&lines).Error; err != nil {
+		// Pakai cache lama jika DB error
+		fmt.Printf("Error loading retail lines: %v\n", err)
+		return lineRegistry.lines
+	}
+	lineRegistry.lines = lines
+	lineRegistry.loadedAt = time.Now()
+	return lines
+}
+
+// Paksa registry dibaca ulang pada akses berikutnya
+func invalidateLineRegistry() {
+	lineRegistry.Lock()
+	lineRegistry.loadedAt = time.Time{}
+	lineRegistry.Unlock()
+}
+
+// Cari line di registry (hanya line aktif)
+func getRetailLine(line string) (models.RetailLine, bool) {
+	line = strings.ToLower(line)
+	for _, l := range getRetailLines() {
+		if l.Line == line && l.Active {
+			return l, true
+		}
+	}
+	return models.RetailLine{}, false
+}
+
+// Batas rollover total_counter line dari registry, 0 jika belum dikonfigurasi
+func counterRollover(line string) int64 {
+	l, _ := getRetailLine(line)
+	return l.CounterRollover
+}
+
+// Cek apakah line terdaftar dan aktif
+func isRetailLine(line string) bool {
+	_, ok := getRetailLine(line)
+	return ok
+}
+
+// Daftar id line aktif untuk endpoint semua line
+func activeRetailLines() []string {
+	var lines []string
+	for _, l := range getRetailLines() {
+		if l.Active {
+			lines = append(lines, l.Line)
+		}
+	}
+	return lines
+}
+
+// Query GORM ke tabel data line
+func retailQuery(line string) *gorm.DB {
+	return config.DB.Scopes(models.RetailTable(getTableByLine(line))).Model(&models.RetailRecord{})
+}
+
+// Validasi isi registry sebelum disimpan
+func validateRetailLine(l models.RetailLine) error {
+	if !identifierPattern.MatchString(l.Line) {
+		return fmt.Errorf("line hanya boleh berisi huruf, angka dan underscore")
+	}
+	if !identifierPattern.MatchString(l.DataTable) {
+		return fmt.Errorf("table_name hanya boleh berisi huruf, angka dan underscore")
+	}
+	if l.RatedSpeed < 0 || l.HeadsPerMachine < 0 || l.CounterRollover < 0 {
+		return fmt.Errorf("rated_speed, heads_per_machine dan counter_rollover tidak boleh negatif")
+	}
+	return nil
+}
+
+// Input registry. Semua field pointer supaya PUT hanya mengubah field yang dikirim
+// (rated_speed atau counter_rollover yang tidak dikirim tidak menjadi 0).
+type retailLineInput struct {
+	Line            *string  `json:"line"`
+	DataTable       *string  `json:"table_name"`
+	DisplayName     *string  `json:"display_name"`
+	Area            *string  `json:"area"`
+	RatedSpeed      *float64 `json:"rated_speed"`
+	HeadsPerMachine *int     `json:"heads_per_machine"`
+	SortOrder       *int     `json:"sort_order"`
+	CounterRollover *int64   `json:"counter_rollover"`
+	Active          *bool    `json:"active"`
+}
+
+func (in retailLineInput) apply(l *models.RetailLine) {
+	if in.Line != nil {
+		l.Line = strings.ToLower(*in.Line)
+	}
+	if in.DataTable != nil {
+		l.DataTable = *in.DataTable
+	}
+	if l.DataTable == "" {
+		l.DataTable = "retail_" + l.Line
+	}
+	if in.DisplayName != nil {
+		l.DisplayName = *in.DisplayName
+	}
+	if l.DisplayName == "" {
+		l.DisplayName = fmt.Sprintf("Line %s", l.Line)
+	}
+	if in.Area != nil {
+		l.Area = *in.Area
+	}
+	if in.RatedSpeed != nil {
+		l.RatedSpeed = *in.RatedSpeed
+	}
+	if in.HeadsPerMachine != nil {
+		l.HeadsPerMachine = *in.HeadsPerMachine
+	}
+	if in.SortOrder != nil {
+		l.SortOrder = *in.SortOrder
+	}
+	if in.CounterRollover != nil {
+		l.CounterRollover = *in.CounterRollover
+	}
+	if in.Active != nil {
+		l.Active = *in.Active
+	}
+}
+
+// GetRetailLines -> isi registry line retail
+func GetRetailLines(c *gin.Context) {
+	var lines []models.RetailLine
+	query := config.DB.Order("sort_order ASC, line ASC")
+	if c.Query("all") != "true" {
+		query = query.Where("active = ?", true)
+	}
+	if area := c.Query("area"); area != "" {
+		query = query.Where("area = ?", area)
+	}
+
+	if err := query.Find(&lines).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil daftar line", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "count": len(lines), "data": lines})
+}
+
+// CreateRetailLine -> daftarkan line baru (misal d15 saat commissioning)
+func CreateRetailLine(c *gin.Context) {
+	var input retailLineInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Body tidak valid", "error": err.Error()})
+		return
+	}
+
+	line := models.RetailLine{Active: true}
+	input.apply(&line)
+	if err := validateRetailLine(line); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	if err := config.DB.Create(&line).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan line", "error": err.Error()})
+		return
+	}
+	invalidateLineRegistry()
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": line})
+}
+
+// UpdateRetailLine -> ubah registry line berdasarkan id
+func UpdateRetailLine(c *gin.Context) {
+	var line models.RetailLine
+	if err := config.DB.First(&line, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Line tidak ditemukan"})
+		return
+	}
+
+	var input retailLineInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Body tidak valid", "error": err.Error()})
+		return
+	}
+	input.apply(&line)
+	if err := validateRetailLine(line); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	if err := config.DB.Save(&line).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengubah line", "error": err.Error()})
+		return
+	}
+	invalidateLineRegistry()
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": line})
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"backend-golang/models"
+)
+
+func TestRetailLineInputApply(t *testing.T) {
+	existing := models.RetailLine{Line: "d5", DataTable: "retail_d5_new", DisplayName: "Filling D5", RatedSpeed: 120, HeadsPerMachine: 4, CounterRollover: 65535, Active: true}
+
+	tests := []struct {
+		name string
+		body string
+		want models.RetailLine
+	}{
+		{name: "body kosong tidak mengubah apa pun", body: `{}`, want: existing},
+		{
+			name: "hanya rated_speed",
+			body: `{"rated_speed": 150}`,
+			want: models.RetailLine{Line: "d5", DataTable: "retail_d5_new", DisplayName: "Filling D5", RatedSpeed: 150, HeadsPerMachine: 4, CounterRollover: 65535, Active: true},
+		},
+		{
+			name: "nonaktifkan line",
+			body: `{"active": false}`,
+			want: models.RetailLine{Line: "d5", DataTable: "retail_d5_new", DisplayName: "Filling D5", RatedSpeed: 120, HeadsPerMachine: 4, CounterRollover: 65535},
+		},
+		{
+			name: "display_name kosong kembali ke default",
+			body: `{"display_name": ""}`,
+			want: models.RetailLine{Line: "d5", DataTable: "retail_d5_new", DisplayName: "Line d5", RatedSpeed: 120, HeadsPerMachine: 4, CounterRollover: 65535, Active: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input retailLineInput
+			if err := json.Unmarshal([]byte(tt.body), &input); err != nil {
+				t.Fatal(err)
+			}
+			got := existing
+			input.apply(&got)
+			if got != tt.want {
+				t.Errorf("apply = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"backend-golang/shiftcal"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Hasil perhitungan OEE untuk satu periode (shift atau satu hari)
+type oeeResult struct {
+	PlannedMinutes      float64
+	RuntimeMinutes      float64
+	TotalCounter        float64
+	Availability        float64
+	Performance         float64
+	Quality             float64
+	OEE                 float64
+	AvailabilityLossMin float64
+	PerformanceLossMin  float64
+	QualityLossMin      float64
+	ChangeoverLossMin   float64
+}
+
+// Hitung OEE = availability x performance x quality beserta loss menit tiap faktor.
+// idealOutput adalah output target selama mesin jalan (runtime x rated speed x head),
+// fillingCapacity adalah penyebut good filling (runtime x main_speed x head) seperti di OutputGagalFilling.
+func calculateOEE(plannedMinutes, runtimeMinutes, totalCounter, idealOutput, fillingCapacity float64) oeeResult {
+	r := oeeResult{
+		PlannedMinutes: plannedMinutes,
+		RuntimeMinutes: runtimeMinutes,
+		TotalCounter:   totalCounter,
+	}
+	if plannedMinutes <= 0 {
+		return r
+	}
+
+	// Availability: runtime dibanding waktu terencana
+	runtime := runtimeMinutes
+	if runtime > plannedMinutes {
+		runtime = plannedMinutes
+	}
+	r.Availability = runtime / plannedMinutes
+
+	// Performance: output aktual dibanding output ideal selama mesin jalan
+	netRunMinutes := 0.0
+	if runtime > 0 && idealOutput > 0 {
+		r.Performance = totalCounter / idealOutput
+		if r.Performance > 1 {
+			r.Performance = 1
+		}
+		netRunMinutes = runtime * r.Performance
+	}
+
+	// Quality: proxy good filling, dibatasi maksimal 100%
+	if fillingCapacity > 0 {
+		r.Quality = totalCounter / fillingCapacity
+		if r.Quality > 1 {
+			r.Quality = 1
+		}
+	}
+	fullyProductiveMinutes := netRunMinutes * r.Quality
+
+	r.OEE = r.Availability * r.Performance * r.Quality
+	r.AvailabilityLossMin = plannedMinutes - runtime
+	r.PerformanceLossMin = runtime - netRunMinutes
+	r.QualityLossMin = netRunMinutes - fullyProductiveMinutes
+
+	return r
+}
+
+// Pisahkan waktu changeover dari availability loss sebagai kategori loss sendiri
+func (r oeeResult) withChangeover(changeoverMinutes float64) oeeResult {
+	if changeoverMinutes > r.AvailabilityLossMin {
+		changeoverMinutes = r.AvailabilityLossMin
+	}
+	if changeoverMinutes < 0 {
+		changeoverMinutes = 0
+	}
+	r.ChangeoverLossMin = changeoverMinutes
+	r.AvailabilityLossMin -= changeoverMinutes
+	return r
+}
+
+// Ubah hasil OEE ke format response (persen dan menit)
+func (r oeeResult) toResponse() gin.H {
+	return gin.H{
+		"planned_minutes": r.PlannedMinutes,
+		"runtime_minutes": r.RuntimeMinutes,
+		"total_counter":   r.TotalCounter,
+		"availability":    r.Availability * 100,
+		"performance":     r.Performance * 100,
+		"quality":         r.Quality * 100,
+		"oee":             r.OEE * 100,
+		"losses": gin.H{
+			"availability_minutes": r.AvailabilityLossMin,
+			"performance_minutes":  r.PerformanceLossMin,
+			"quality_minutes":      r.QualityLossMin,
+			"changeover_minutes":   r.ChangeoverLossMin,
+		},
+	}
+}
+
+// Parse parameter date (YYYY-MM-DD) ke Asia/Jakarta, default hari ini
+func parseRetailDate(c *gin.Context) (time.Time, error) {
+	baseDate := time.Now().In(jakartaLoc)
+	if dateParam := c.Query("date"); dateParam != "" {
+		parsedDate, err := time.Parse("2006-01-02", dateParam)
+		if err != nil {
+			return time.Time{}, err
+		}
+		baseDate = time.Date(parsedDate.Year(), parsedDate.Month(), parsedDate.Day(), 0, 0, 0, 0, jakartaLoc)
+	}
+	return baseDate, nil
+}
+
+// Controller untuk OEE per shift dan per hari
+func RetailOEE(c *gin.Context) {
+	line := c.Param("line")
+
+	// Validasi line
+	if !isRetailLine(line) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Line %s tidak terdaftar", line)})
+		return
+	}
+
+	baseDate, err := parseRetailDate(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal salah. Gunakan YYYY-MM-DD"})
+		return
+	}
+
+	now := time.Now().In(jakartaLoc)
+	shifts := []gin.H{}
+	var dayPlanned, dayRuntime, dayCounter, dayIdeal, dayCapacity, dayChangeover float64
+	target := getLineTargetOrDefault(line, "", baseDate)
+
+	calendar, err := shiftcal.ForDate(line, baseDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal mengambil kalender shift: %v", err)})
+		return
+	}
+
+	for _, s := range calendar {
+		start, end := s.Start, s.End
+
+		plannedMinutes := float64(s.ActualMinutes(now))
+		runtime, _ := getShiftRunStopMinutes(line, start, end, now)
+		runtimeMinutes := float64(runtime)
+		counter, err := getShiftCounter(line, start, end, now)
+		if err != nil {
+			fmt.Printf("Error getting counter for %s: %v\n", line, err)
+		}
+		totalCounter := float64(counter.Produced)
+		mainSpeed := float64(getLastMainSpeed(line, start, end, now))
+		idealOutput := runtimeMinutes * target.RatePerMinute()
+		fillingCapacity := runtimeMinutes * mainSpeed * float64(target.HeadsPerMachine)
+
+		// Changeover = jeda antara dua production run dengan produk berbeda
+		changeover := 0.0
+		if runs, err := getProductionRuns(line, start, end); err != nil {
+			fmt.Printf("Error getting production runs for %s: %v\n", line, err)
+		} else {
+			changeover = changeoverMinutes(splitByRuns(runs, start, end), now)
+		}
+
+		result := calculateOEE(plannedMinutes, runtimeMinutes, totalCounter, idealOutput, fillingCapacity).withChangeover(changeover)
+
+		dayPlanned += plannedMinutes
+		dayRuntime += runtimeMinutes
+		dayCounter += totalCounter
+		dayIdeal += idealOutput
+		dayCapacity += fillingCapacity
+		dayChangeover += result.ChangeoverLossMin
+
+		shift := result.toResponse()
+		shift["shift"] = s.No
+		shift["start_time"] = start
+		shift["end_time"] = end
+		shift["main_speed"] = mainSpeed
+		shift["counter_resets"] = counter.Resets
+		shift["data_quality"] = retailDataQuality(line, start, end, now)
+		shifts = append(shifts, shift)
+	}
+
+	day := calculateOEE(dayPlanned, dayRuntime, dayCounter, dayIdeal, dayCapacity).withChangeover(dayChangeover)
+
+	c.JSON(http.StatusOK, gin.H{
+		"line":          line,
+		"date":          baseDate.Format("2006-01-02"),
+		"current_shift": getCurrentShift(line, now),
+		"target":        target,
+		"shifts":        shifts,
+		"day":           day.toResponse(),
+	})
+}
@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"backend-golang/shiftcal"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Jumlah maksimal line yang di-query bersamaan oleh overview
+const overviewWorkers = 4
+
+// Hitung ringkasan KPI satu line untuk semua shift pada tanggal tertentu
+func buildLineOverview(line string, baseDate, now time.Time) (gin.H, error) {
+	calendar, err := shiftcal.ForDate(line, baseDate)
+	if err != nil {
+		return nil, fmt.Errorf("kalender shift: %w", err)
+	}
+
+	target, err := getLineTarget(line, "", baseDate)
+	if err != nil {
+		return nil, fmt.Errorf("target: %w", err)
+	}
+
+	shifts := []gin.H{}
+	var totalRuntime, totalDowntime, totalActual, totalCounter, totalExpected, totalSamples int64
+	var totalCapacity float64
+
+	for _, s := range calendar {
+		runStop, err := getShiftRunStop(line, s.Start, s.End, now)
+		if err != nil {
+			return nil, fmt.Errorf("shift %d runtime: %w", s.No, err)
+		}
+		counter, err := getShiftCounter(line, s.Start, s.End, now)
+		if err != nil {
+			return nil, fmt.Errorf("shift %d counter: %w", s.No, err)
+		}
+		mainSpeed, err := getShiftMainSpeed(line, s.Start, s.End, now)
+		if err != nil {
+			return nil, fmt.Errorf("shift %d main speed: %w", s.No, err)
+		}
+
+		runtimeMinutes := int64(runStop.Run.Minutes())
+		downtimeMinutes := int64(runStop.Stop.Minutes())
+		actualMinutes := s.ActualMinutes(now)
+
+		var uptime, downtime float64
+		if actualMinutes > 0 {
+			uptime = float64(runtimeMinutes) / float64(actualMinutes) * 100
+			downtime = float64(downtimeMinutes) / float64(actualMinutes) * 100
+		}
+
+		capacity := float64(runtimeMinutes) * float64(mainSpeed) * float64(target.HeadsPerMachine)
+		goodFilling := 0.0
+		if capacity > 0 {
+			goodFilling = float64(counter.Produced) / capacity * 100
+			if goodFilling > 100 {
+				goodFilling = 100
+			}
+		}
+
+		totalRuntime += runtimeMinutes
+		totalDowntime += downtimeMinutes
+		totalActual += actualMinutes
+		totalCounter += counter.Produced
+		totalCapacity += capacity
+		expected := expectedSamples(s.Start, s.End, now)
+		totalExpected += expected
+		totalSamples += runStop.Samples
+
+		shifts = append(shifts, gin.H{
+			"shift":                  s.No,
+			"start_time":             s.Start,
+			"end_time":               s.End,
+			"runtime_total_minutes":  runtimeMinutes,
+			"downtime_total_minutes": downtimeMinutes,
+			"actual_shift_minutes":   actualMinutes,
+			"uptime":                 uptime,
+			"downtime":               downtime,
+			"total_counter":          counter.Produced,
+			"counter_resets":         counter.Resets,
+			"main_speed":             mainSpeed,
+			"good_filling":           goodFilling,
+			"data_quality":           dataQuality(expected, runStop.Samples),
+		})
+	}
+
+	var dayUptime, dayDowntime, dayGoodFilling float64
+	if totalActual > 0 {
+		dayUptime = float64(totalRuntime) / float64(totalActual) * 100
+		dayDowntime = float64(totalDowntime) / float64(totalActual) * 100
+	}
+	if totalCapacity > 0 {
+		dayGoodFilling = float64(totalCounter) / totalCapacity * 100
+		if dayGoodFilling > 100 {
+			dayGoodFilling = 100
+		}
+	}
+
+	return gin.H{
+		"line":          line,
+		"current_shift": getCurrentShift(line, now),
+		"shifts":        shifts,
+		"day": gin.H{
+			"runtime_total_minutes":  totalRuntime,
+			"downtime_total_minutes": totalDowntime,
+			"actual_shift_minutes":   totalActual,
+			"uptime":                 dayUptime,
+			"downtime":               dayDowntime,
+			"total_counter":          totalCounter,
+			"good_filling":           dayGoodFilling,
+			"data_quality":           dataQuality(totalExpected, totalSamples),
+		},
+	}, nil
+}
+
+// Jalankan fn untuk setiap line secara paralel dengan worker pool terbatas.
+// Error atau panic pada satu line dilaporkan di hasil line tersebut, tidak menggagalkan line lain.
+func runPerLine(lines []string, workers int, fn func(line string) (gin.H, error)) []gin.H {
+	results := make([]gin.H, len(lines))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	if workers > len(lines) {
+		workers = len(lines)
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = runLineJob(lines[idx], fn)
+			}
+		}()
+	}
+
+	for idx := range lines {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+func runLineJob(line string, fn func(line string) (gin.H, error)) (result gin.H) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = gin.H{"line": line, "success": false, "error": fmt.Sprintf("panic: %v", r)}
+		}
+	}()
+
+	data, err := fn(line)
+	if err != nil {
+		return gin.H{"line": line, "success": false, "error": err.Error()}
+	}
+	data["success"] = true
+	return data
+}
+
+// Controller untuk overview semua line retail dalam satu response
+func RetailOverview(c *gin.Context) {
+	baseDate, err := parseRetailDate(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal salah. Gunakan YYYY-MM-DD"})
+		return
+	}
+
+	now := time.Now().In(jakartaLoc)
+	lines := runPerLine(activeRetailLines(), overviewWorkers, func(line string) (gin.H, error) {
+		return buildLineOverview(line, baseDate, now)
+	})
+
+	failed := 0
+	for _, l := range lines {
+		if l["success"] != true {
+			failed++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"date":         baseDate.Format("2006-01-02"),
+		"generated_at": now,
+		"total_lines":  len(lines),
+		"failed_lines": failed,
+		"lines":        lines,
+	})
+}
@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"backend-golang/config"
+	"backend-golang/shiftcal"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Batas rentang tanggal untuk endpoint yang membaca data mentah per detik
+const maxRangeDays = 92
+
+// Parse from/to menjadi tanggal Asia/Jakarta dan batasi panjang rentang
+func parseDateRangeDates(c *gin.Context) (time.Time, time.Time, error) {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	fromDate, _ := time.ParseInLocation("2006-01-02", from, jakartaLoc)
+	toDate, _ := time.ParseInLocation("2006-01-02", to, jakartaLoc)
+	if toDate.Sub(fromDate) > maxRangeDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("rentang tanggal maksimal %d hari", maxRangeDays)
+	}
+	return fromDate, toDate, nil
+}
+
+// Total detik mesin jalan, dihitung dari selisih waktu antar sampel
+func runSeconds(samples []RetailSample, windowEnd time.Time) float64 {
+	times := make([]time.Time, len(samples))
+	for i, s := range samples {
+		times[i] = s.Ts
+	}
+	running := func(i int) bool { return samples[i].StartMesin == 1 }
+	return integrateDuration(times, running, windowEnd, maxSampleGap()).Seconds()
+}
+
+// Ambil waktu mulai stop yang diberi alasan planned (changeover, cleaning, dll)
+func getPlannedStopStarts(line, from, to string) (map[string]bool, error) {
+	var starts []string
+	err := config.DB.Table("stop_reasons AS s").
+		Joins("JOIN downtime_reasons r ON r.code = s.reason_code").
+		Where("s.line = ? AND s.date BETWEEN ? AND ? AND r.planned = ?", strings.ToLower(line), from, to, true).
+		Pluck("s.stop_start", &starts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]bool, len(starts))
+	for _, s := range starts {
+		result[s] = true
+	}
+	return result, nil
+}
+
+// Total reliability satu line dalam rentang tanggal
+type reliabilityTotals struct {
+	RunSeconds     float64
+	RepairSeconds  float64
+	PlannedSeconds float64
+	Failures       int
+	PlannedStops   int
+}
+
+// Akumulasi reliability shift demi shift. Stop yang masih berjalan di akhir shift disimpan
+// (carry) dan disambung dengan stop di awal shift berikutnya jika shift bersambung, jadi
+// satu stop lintas batas shift dihitung satu kali. Jeda sampai maxGap antara akhir shift dan
+// awal shift berikutnya (pola bawaan berjeda 1 detik) maupun antara awal shift dan sampel
+// stop pertama masih dianggap bersambung.
+type reliabilityAccumulator struct {
+	planned      map[string]bool // waktu mulai stop yang diberi alasan planned
+	maxGap       time.Duration
+	totals       reliabilityTotals
+	carry        *StopEvent
+	carryPlanned bool
+	prevEnd      time.Time
+}
+
+func newReliabilityAccumulator(planned map[string]bool) *reliabilityAccumulator {
+	return &reliabilityAccumulator{planned: planned, maxGap: maxSampleGap()}
+}
+
+func (a *reliabilityAccumulator) countStop(e StopEvent, isPlanned bool) {
+	if isPlanned {
+		a.totals.PlannedStops++
+		a.totals.PlannedSeconds += e.DurationSeconds
+		return
+	}
+	a.totals.Failures++
+	a.totals.RepairSeconds += e.DurationSeconds
+}
+
+func (a *reliabilityAccumulator) isPlanned(t time.Time) bool {
+	return a.planned[t.Format("2006-01-02 15:04:05")]
+}
+
+// Tambahkan satu shift (shift harus diberikan berurutan)
+func (a *reliabilityAccumulator) addShift(s shiftcal.Shift, samples []RetailSample, now time.Time) {
+	windowEnd := integrationEnd(s.End, now)
+	a.totals.RunSeconds += runSeconds(samples, windowEnd)
+	events := extractStopEvents(samples, s.End, now, a.maxGap)
+
+	// Alasan stop disimpan per shift, jadi stop lintas shift dianggap planned
+	// jika salah satu potongannya diberi alasan planned
+	continuedPlanned := false
+	if a.carry != nil {
+		if s.Start.Sub(a.prevEnd) <= a.maxGap && len(events) > 0 && events[0].Start.Sub(s.Start) <= a.maxGap {
+			continuedPlanned = a.carryPlanned || a.isPlanned(events[0].Start)
+			events[0].Start = a.carry.Start
+			events[0].DurationSeconds = events[0].End.Sub(a.carry.Start).Seconds()
+		} else {
+			a.countStop(*a.carry, a.carryPlanned)
+		}
+		a.carry = nil
+	}
+
+	for i, e := range events {
+		if e.Open {
+			continue
+		}
+		isPlanned := a.isPlanned(e.Start) || (i == 0 && continuedPlanned)
+		// Stop yang masih berjalan sampai akhir data shift disimpan untuk shift berikutnya
+		last := samples[len(samples)-1]
+		if i == len(events)-1 && last.StartMesin == 0 && e.End.Equal(last.Ts) && windowEnd.Sub(last.Ts) <= a.maxGap {
+			stop := e
+			a.carry, a.carryPlanned = &stop, isPlanned
+			continue
+		}
+		a.countStop(e, isPlanned)
+	}
+	a.prevEnd = s.End
+}
+
+// Tutup stop yang masih disimpan dan kembalikan total
+func (a *reliabilityAccumulator) finish() reliabilityTotals {
+	if a.carry != nil {
+		a.countStop(*a.carry, a.carryPlanned)
+		a.carry = nil
+	}
+	return a.totals
+}
+
+// Hitung MTBF dan MTTR satu line dalam rentang tanggal.
+// Stop planned dan stop yang masih berlangsung tidak dihitung sebagai failure.
+func calculateReliability(line string, from, to, now time.Time) (gin.H, error) {
+	shifts, err := shiftcal.ForRange(line, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("kalender shift: %w", err)
+	}
+	planned, err := getPlannedStopStarts(line, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("alasan stop: %w", err)
+	}
+
+	acc := newReliabilityAccumulator(planned)
+	for _, s := range shifts {
+		if now.Before(s.Start) {
+			continue
+		}
+		samples, err := getRetailSamples(line, s.Start, s.End)
+		if err != nil {
+			return nil, fmt.Errorf("shift %d %s: %w", s.No, s.Date, err)
+		}
+		acc.addShift(s, samples, now)
+	}
+	t := acc.finish()
+	runTotal, repairTotal, plannedTotal := t.RunSeconds, t.RepairSeconds, t.PlannedSeconds
+	failures, plannedStops := t.Failures, t.PlannedStops
+
+	var mtbf, mttr, availability float64
+	if failures > 0 {
+		mtbf = runTotal / float64(failures) / 60
+		mttr = repairTotal / float64(failures) / 60
+	}
+	if runTotal+repairTotal > 0 {
+		availability = runTotal / (runTotal + repairTotal) * 100
+	}
+
+	return gin.H{
+		"line":                  line,
+		"from":                  from.Format("2006-01-02"),
+		"to":                    to.Format("2006-01-02"),
+		"run_minutes":           runTotal / 60,
+		"failure_count":         failures,
+		"repair_minutes":        repairTotal / 60,
+		"planned_stop_count":    plannedStops,
+		"planned_stop_minutes":  plannedTotal / 60,
+		"mtbf_minutes":          mtbf,
+		"mttr_minutes":          mttr,
+		"inherent_availability": availability,
+	}, nil
+}
+
+// Controller untuk MTBF/MTTR satu line
+func RetailReliability(c *gin.Context) {
+	line := c.Param("line")
+
+	// Validasi line
+	if !isRetailLine(line) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Line %s tidak terdaftar", line)})
+		return
+	}
+
+	from, to, err := parseDateRangeDates(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := calculateReliability(line, from, to, time.Now().In(jakartaLoc))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal menghitung reliability: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Controller untuk ranking reliability semua line (paling tidak reliable di atas)
+func RetailReliabilityRanking(c *gin.Context) {
+	from, to, err := parseDateRangeDates(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now().In(jakartaLoc)
+	lines := runPerLine(activeRetailLines(), overviewWorkers, func(line string) (gin.H, error) {
+		return calculateReliability(line, from, to, now)
+	})
+
+	// Urutkan berdasarkan MTBF terkecil; line tanpa failure dan line gagal di bawah
+	sortKey := func(l gin.H) (int, float64) {
+		if l["success"] != true {
+			return 2, 0
+		}
+		if l["failure_count"].(int) == 0 {
+			return 1, 0
+		}
+		return 0, l["mtbf_minutes"].(float64)
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		gi, vi := sortKey(lines[i])
+		gj, vj := sortKey(lines[j])
+		if gi != gj {
+			return gi < gj
+		}
+		return vi < vj
+	})
+	for i := range lines {
+		lines[i]["rank"] = i + 1
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":  from.Format("2006-01-02"),
+		"to":    to.Format("2006-01-02"),
+		"lines": lines,
+	})
+}
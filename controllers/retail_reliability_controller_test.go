@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"backend-golang/shiftcal"
+)
+
+// Sampel per detik dari from sampai to (inklusif) dengan status start_mesin yang sama
+func machineSamples(from, to time.Time, startMesin int) []RetailSample {
+	var samples []RetailSample
+	for ts := from; !ts.After(to); ts = ts.Add(time.Second) {
+		samples = append(samples, RetailSample{Ts: ts, StartMesin: startMesin})
+	}
+	return samples
+}
+
+func TestReliabilityAccumulatorShiftBoundary(t *testing.T) {
+	day := time.Date(2026, 1, 5, 0, 0, 0, 0, jakartaLoc)
+	at := func(h, m, s int) time.Time {
+		return day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second)
+	}
+
+	// Pola bawaan hari biasa: shift 2 mulai 1 detik setelah shift 1 selesai
+	shift1 := shiftcal.Shift{No: 1, Start: at(6, 0, 0), End: at(14, 0, 0)}
+	shift2 := shiftcal.Shift{No: 2, Start: at(14, 0, 1), End: at(22, 0, 0)}
+	now := at(23, 0, 0)
+
+	// Mesin jalan, berhenti 13:59:30 sampai 14:01:00, lalu jalan lagi
+	shift1Samples := append(machineSamples(at(13, 59, 0), at(13, 59, 29), 1), machineSamples(at(13, 59, 30), at(14, 0, 0), 0)...)
+	shift2Samples := append(machineSamples(at(14, 0, 1), at(14, 1, 0), 0), machineSamples(at(14, 1, 1), at(14, 1, 10), 1)...)
+	// Data shift 2 baru masuk 5 detik setelah shift mulai
+	lateShift2 := append(machineSamples(at(14, 0, 6), at(14, 1, 0), 0), machineSamples(at(14, 1, 1), at(14, 1, 10), 1)...)
+	// Shift berikutnya tidak bersambung (jeda lebih dari maxSampleGap)
+	detached := shiftcal.Shift{No: 2, Start: at(14, 5, 0), End: at(22, 0, 0)}
+	detachedSamples := append(machineSamples(at(14, 5, 0), at(14, 5, 30), 0), machineSamples(at(14, 5, 31), at(14, 5, 40), 1)...)
+
+	tests := []struct {
+		name         string
+		second       shiftcal.Shift
+		samples      []RetailSample
+		planned      map[string]bool
+		failures     int
+		repair       float64
+		plannedStops int
+	}{
+		{name: "stop melewati 14:00 dihitung sekali", second: shift2, samples: shift2Samples, failures: 1, repair: 91},
+		{name: "sampel pertama shift terlambat", second: shift2, samples: lateShift2, failures: 1, repair: 91},
+		{name: "shift tidak bersambung", second: detached, samples: detachedSamples, failures: 2, repair: 30 + 31},
+		{
+			name:         "potongan shift berikutnya planned",
+			second:       shift2,
+			samples:      shift2Samples,
+			planned:      map[string]bool{"2026-01-05 14:00:01": true},
+			plannedStops: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acc := newReliabilityAccumulator(tt.planned)
+			acc.addShift(shift1, shift1Samples, now)
+			acc.addShift(tt.second, tt.samples, now)
+			got := acc.finish()
+
+			if got.Failures != tt.failures {
+				t.Errorf("failures = %d, want %d", got.Failures, tt.failures)
+			}
+			if got.RepairSeconds != tt.repair {
+				t.Errorf("repair = %v detik, want %v", got.RepairSeconds, tt.repair)
+			}
+			if got.PlannedStops != tt.plannedStops {
+				t.Errorf("planned stops = %d, want %d", got.PlannedStops, tt.plannedStops)
+			}
+		})
+	}
+}
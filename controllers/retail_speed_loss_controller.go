@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend-golang/shiftcal"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Batas atas band kecepatan dalam persen rated speed. Band terakhir (>= batas terakhir)
+// dianggap full speed, di bawahnya reduced speed. Bisa diganti lewat query parameter bands.
+const defaultSpeedBands = "50,80,95"
+
+// Parse batas band "50,80,95" (persen, naik)
+func parseSpeedBands(spec string) ([]float64, error) {
+	var bounds []float64
+	prev := 0.0
+	for _, part := range strings.Split(spec, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || v <= prev {
+			return nil, fmt.Errorf("batas band %q harus angka dan lebih besar dari batas sebelumnya", part)
+		}
+		prev = v
+		bounds = append(bounds, v)
+	}
+	return bounds, nil
+}
+
+// Hitung speed loss satu shift dari sampel per detik.
+// Setiap sampel mesin jalan diberi bobot selisih waktu ke sampel berikutnya (sampel terakhir
+// sampai windowEnd), jeda di atas maxSampleGap tidak dihitung seperti getShiftRunStop.
+func calculateSpeedLoss(samples []RetailSample, bounds []float64, target lineTarget, windowEnd time.Time) gin.H {
+	bandSeconds := make([]float64, len(bounds)+1)
+	var runSeconds, speedWeighted, lostPacks, reducedSeconds, fullSeconds float64
+	gap := maxSampleGap()
+
+	for i, sample := range samples {
+		if sample.StartMesin != 1 {
+			continue
+		}
+		next := windowEnd
+		if i+1 < len(samples) {
+			next = samples[i+1].Ts
+		}
+		dt := sampleSpan(sample.Ts, next, gap).Seconds()
+		speed := float64(sample.MainSpeed)
+
+		runSeconds += dt
+		speedWeighted += speed * dt
+
+		percent := 0.0
+		if target.RatedSpeed > 0 {
+			percent = speed / target.RatedSpeed * 100
+		}
+		band := len(bounds)
+		for b, limit := range bounds {
+			if percent < limit {
+				band = b
+				break
+			}
+		}
+		bandSeconds[band] += dt
+
+		if band == len(bounds) {
+			fullSeconds += dt
+		} else {
+			reducedSeconds += dt
+		}
+
+		// Pack yang hilang karena jalan di bawah rated speed
+		if speed < target.RatedSpeed {
+			lostPacks += (target.RatedSpeed - speed) * float64(target.HeadsPerMachine) * dt / 60
+		}
+	}
+
+	var bands []gin.H
+	lower := 0.0
+	for b, seconds := range bandSeconds {
+		var name string
+		if b < len(bounds) {
+			name = fmt.Sprintf("%g-%g%%", lower, bounds[b])
+			lower = bounds[b]
+		} else {
+			name = fmt.Sprintf(">=%g%%", lower)
+		}
+		share := 0.0
+		if runSeconds > 0 {
+			share = seconds / runSeconds * 100
+		}
+		bands = append(bands, gin.H{
+			"band":       name,
+			"minutes":    seconds / 60,
+			"percentage": share,
+		})
+	}
+
+	avgSpeed, avgPercent := 0.0, 0.0
+	if runSeconds > 0 {
+		avgSpeed = speedWeighted / runSeconds
+		if target.RatedSpeed > 0 {
+			avgPercent = avgSpeed / target.RatedSpeed * 100
+		}
+	}
+
+	return gin.H{
+		"running_minutes":         runSeconds / 60,
+		"avg_main_speed":          avgSpeed,
+		"avg_speed_percentage":    avgPercent,
+		"last_main_speed":         lastMainSpeed(samples),
+		"full_speed_minutes":      fullSeconds / 60,
+		"reduced_speed_minutes":   reducedSeconds / 60,
+		"lost_packs":              int64(lostPacks),
+		"lost_equivalent_minutes": lostEquivalentMinutes(lostPacks, target),
+		"bands":                   bands,
+	}
+}
+
+// main_speed sampel terakhir, sama dengan nilai dari getLastMainSpeed
+func lastMainSpeed(samples []RetailSample) int64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	return int64(samples[len(samples)-1].MainSpeed)
+}
+
+// Konversi pack hilang ke menit mesin jalan penuh pada rated speed
+func lostEquivalentMinutes(lostPacks float64, target lineTarget) float64 {
+	if rate := target.RatePerMinute(); rate > 0 {
+		return lostPacks / rate
+	}
+	return 0
+}
+
+// Controller untuk speed loss per shift
+func RetailSpeedLoss(c *gin.Context) {
+	line := c.Param("line")
+
+	// Validasi line
+	if !isRetailLine(line) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Line %s tidak terdaftar", line)})
+		return
+	}
+
+	baseDate, err := parseRetailDate(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal salah. Gunakan YYYY-MM-DD"})
+		return
+	}
+
+	bounds, err := parseSpeedBands(c.DefaultQuery("bands", defaultSpeedBands))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	calendar, err := shiftcal.ForDate(line, baseDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal mengambil kalender shift: %v", err)})
+		return
+	}
+	selected, err := filterShifts(calendar, c.Query("shift"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now().In(jakartaLoc)
+	target := getLineTargetOrDefault(line, "", baseDate)
+	shifts := []gin.H{}
+
+	for _, s := range selected {
+		var samples []RetailSample
+		if !now.Before(s.Start) {
+			samples, err = getRetailSamples(line, s.Start, s.End)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal mengambil data: %v", err)})
+				return
+			}
+		}
+
+		shift := calculateSpeedLoss(samples, bounds, target, integrationEnd(s.End, now))
+		shift["shift"] = s.No
+		shift["start_time"] = s.Start
+		shift["end_time"] = s.End
+		shifts = append(shifts, shift)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"line":          line,
+		"date":          baseDate.Format("2006-01-02"),
+		"current_shift": getCurrentShift(line, now),
+		"target":        target,
+		"shifts":        shifts,
+	})
+}
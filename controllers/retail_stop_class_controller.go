@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend-golang/shiftcal"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Bucket default: micro < 2 menit, minor < 10 menit, major sisanya.
+// Bisa diganti lewat env STOP_BUCKETS atau query parameter buckets dengan format yang sama.
+const defaultStopBuckets = "micro:2,minor:10,major"
+
+// Satu kelas durasi stop, MaxMinutes = 0 berarti tanpa batas atas
+type stopBucket struct {
+	Name       string  `json:"name"`
+	MaxMinutes float64 `json:"max_minutes,omitempty"`
+}
+
+// Parse spesifikasi bucket "nama:batas_menit,...,nama_terakhir"
+func parseStopBuckets(spec string) ([]stopBucket, error) {
+	var buckets []stopBucket
+	parts := strings.Split(spec, ",")
+	prev := 0.0
+
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		last := i == len(parts)-1
+
+		name, limit, hasLimit := strings.Cut(part, ":")
+		if name == "" {
+			return nil, fmt.Errorf("nama bucket kosong pada %q", part)
+		}
+		if last {
+			if hasLimit {
+				return nil, fmt.Errorf("bucket terakhir (%s) tidak boleh punya batas", name)
+			}
+			buckets = append(buckets, stopBucket{Name: name})
+			break
+		}
+		if !hasLimit {
+			return nil, fmt.Errorf("bucket %s harus punya batas menit", name)
+		}
+
+		maxMinutes, err := strconv.ParseFloat(limit, 64)
+		if err != nil || maxMinutes <= prev {
+			return nil, fmt.Errorf("batas bucket %s harus angka dan lebih besar dari bucket sebelumnya", name)
+		}
+		prev = maxMinutes
+		buckets = append(buckets, stopBucket{Name: name, MaxMinutes: maxMinutes})
+	}
+
+	if len(buckets) < 2 {
+		return nil, fmt.Errorf("minimal 2 bucket")
+	}
+	return buckets, nil
+}
+
+// Tentukan bucket untuk satu durasi stop
+func classifyStop(buckets []stopBucket, durationSeconds float64) int {
+	minutes := durationSeconds / 60
+	for i, b := range buckets[:len(buckets)-1] {
+		if minutes < b.MaxMinutes {
+			return i
+		}
+	}
+	return len(buckets) - 1
+}
+
+// Hitung jumlah dan menit stop per bucket
+func summarizeStopBuckets(buckets []stopBucket, events []StopEvent) []gin.H {
+	counts := make([]int, len(buckets))
+	seconds := make([]float64, len(buckets))
+	for _, e := range events {
+		idx := classifyStop(buckets, e.DurationSeconds)
+		counts[idx]++
+		seconds[idx] += e.DurationSeconds
+	}
+
+	summary := make([]gin.H, 0, len(buckets))
+	for i, b := range buckets {
+		summary = append(summary, gin.H{
+			"name":        b.Name,
+			"max_minutes": b.MaxMinutes,
+			"count":       counts[i],
+			"minutes":     seconds[i] / 60,
+		})
+	}
+	return summary
+}
+
+// Controller untuk klasifikasi stop (micro/minor/major) per shift
+func RetailStopClassification(c *gin.Context) {
+	line := c.Param("line")
+
+	// Validasi line
+	if !isRetailLine(line) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Line %s tidak terdaftar", line)})
+		return
+	}
+
+	spec := c.Query("buckets")
+	if spec == "" {
+		spec = os.Getenv("STOP_BUCKETS")
+	}
+	if spec == "" {
+		spec = defaultStopBuckets
+	}
+	buckets, err := parseStopBuckets(spec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Format buckets salah: %v", err)})
+		return
+	}
+
+	baseDate, err := parseRetailDate(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal salah. Gunakan YYYY-MM-DD"})
+		return
+	}
+
+	calendar, err := shiftcal.ForDate(line, baseDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal mengambil kalender shift: %v", err)})
+		return
+	}
+	selected, err := filterShifts(calendar, c.Query("shift"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now().In(jakartaLoc)
+	shifts := []gin.H{}
+	var allEvents []StopEvent
+
+	for _, s := range selected {
+		events, err := getShiftStopEvents(line, s, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal mengambil data stop: %v", err)})
+			return
+		}
+
+		shifts = append(shifts, gin.H{
+			"shift":      s.No,
+			"start_time": s.Start,
+			"end_time":   s.End,
+			"stop_count": len(events),
+			"buckets":    summarizeStopBuckets(buckets, events),
+		})
+		allEvents = append(allEvents, events...)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"line":        line,
+		"date":        baseDate.Format("2006-01-02"),
+		"bucket_spec": buckets,
+		"shifts":      shifts,
+		"day": gin.H{
+			"stop_count": len(allEvents),
+			"buckets":    summarizeStopBuckets(buckets, allEvents),
+		},
+	})
+}
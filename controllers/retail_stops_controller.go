@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend-golang/config"
+	"backend-golang/shiftcal"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Satu baris data mentah retail_dX
+type RetailSample struct {
+	Ts           time.Time `json:"ts"`
+	StartMesin   int       `json:"start_mesin"`
+	TotalCounter int       `json:"total_counter"`
+	MainSpeed    int       `json:"main_speed"`
+}
+
+// Satu kejadian mesin berhenti (start_mesin = 0) dalam satu shift
+type StopEvent struct {
+	Line            string    `json:"line"`
+	Shift           int       `json:"shift"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Open            bool      `json:"open"` // stop masih berlangsung
+	ReasonID        uint      `json:"reason_id,omitempty"`
+	ReasonCode      string    `json:"reason_code,omitempty"`
+	Comment         string    `json:"comment,omitempty"`
+}
+
+// Waktu di DB disimpan tanpa timezone (WIB), samakan ke Asia/Jakarta apa pun setting loc di DSN
+func toJakartaWall(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), jakartaLoc)
+}
+
+// Ambil data mentah retail dalam rentang waktu, urut berdasarkan ts
+func getRetailSamples(line string, start, end time.Time) ([]RetailSample, error) {
+	var samples []RetailSample
+	query := fmt.Sprintf("SELECT ts, start_mesin, total_counter, main_speed FROM %s WHERE ts >= ? AND ts <= ? ORDER BY ts ASC", getTableByLine(line))
+
+	err := config.DB.Raw(query,
+		start.In(jakartaLoc).Format("2006-01-02 15:04:05"),
+		end.In(jakartaLoc).Format("2006-01-02 15:04:05"),
+	).Scan(&samples).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range samples {
+		samples[i].Ts = toJakartaWall(samples[i].Ts)
+	}
+	return samples, nil
+}
+
+// Ekstrak kejadian stop dari transisi start_mesin 1 -> 0 -> 1.
+// Stop yang melewati batas shift dipotong di akhir shift; stop di akhir data ditandai open
+// jika shift belum selesai. Jeda data lebih dari maxGap menutup stop di sampel terakhir
+// sebelum jeda (dihitung satu periode sampling seperti sampleSpan), jadi outage collector
+// tidak ikut menjadi waktu stop.
+func extractStopEvents(samples []RetailSample, windowEnd, now time.Time, maxGap time.Duration) []StopEvent {
+	var events []StopEvent
+	var current *StopEvent
+
+	for i, sample := range samples {
+		if current != nil && i > 0 && sample.Ts.Sub(samples[i-1].Ts) > maxGap {
+			current.DurationSeconds = (current.End.Sub(current.Start) + expectedSamplePeriod).Seconds()
+			events = append(events, *current)
+			current = nil
+		}
+		if sample.StartMesin == 0 {
+			if current == nil {
+				current = &StopEvent{Start: sample.Ts}
+			}
+			current.End = sample.Ts
+			continue
+		}
+		if current != nil {
+			// Stop selesai saat mesin kembali jalan
+			current.End = sample.Ts
+			current.DurationSeconds = current.End.Sub(current.Start).Seconds()
+			events = append(events, *current)
+			current = nil
+		}
+	}
+
+	if current != nil {
+		current.Open = now.Before(windowEnd)
+		current.DurationSeconds = current.End.Sub(current.Start).Seconds()
+		events = append(events, *current)
+	}
+
+	return events
+}
+
+// Ambil kejadian stop satu line dalam satu shift
+func getShiftStopEvents(line string, s shiftcal.Shift, now time.Time) ([]StopEvent, error) {
+	if now.Before(s.Start) {
+		return nil, nil
+	}
+
+	samples, err := getRetailSamples(line, s.Start, s.End)
+	if err != nil {
+		return nil, err
+	}
+
+	events := extractStopEvents(samples, s.End, now, maxSampleGap())
+	for i := range events {
+		events[i].Line = line
+		events[i].Shift = s.No
+	}
+	return events, nil
+}
+
+// Pilih shift dari kalender berdasarkan parameter shift (kosong = semua shift)
+func filterShifts(calendar []shiftcal.Shift, shiftParam string) ([]shiftcal.Shift, error) {
+	if shiftParam == "" {
+		return calendar, nil
+	}
+
+	no, err := strconv.Atoi(shiftParam)
+	if err != nil {
+		return nil, fmt.Errorf("shift harus berupa angka")
+	}
+	for _, s := range calendar {
+		if s.No == no {
+			return []shiftcal.Shift{s}, nil
+		}
+	}
+	return nil, fmt.Errorf("shift %d tidak ada pada tanggal ini", no)
+}
+
+// Controller untuk daftar kejadian stop mesin per shift
+func RetailStopEvents(c *gin.Context) {
+	line := c.Param("line")
+
+	// Validasi line
+	if !isRetailLine(line) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Line %s tidak terdaftar", line)})
+		return
+	}
+
+	baseDate, err := parseRetailDate(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal salah. Gunakan YYYY-MM-DD"})
+		return
+	}
+
+	calendar, err := shiftcal.ForDate(line, baseDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal mengambil kalender shift: %v", err)})
+		return
+	}
+	selected, err := filterShifts(calendar, c.Query("shift"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now().In(jakartaLoc)
+	shifts := []gin.H{}
+	var allEvents []StopEvent
+
+	for _, s := range selected {
+		events, err := getShiftStopEvents(line, s, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal mengambil data stop: %v", err)})
+			return
+		}
+
+		// Lengkapi dengan alasan stop yang sudah diisi supervisor
+		reasons, err := getStopReasonMap(line, events)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal mengambil alasan stop: %v", err)})
+			return
+		}
+		for i := range events {
+			if r, ok := reasons[events[i].Start.Format("2006-01-02 15:04:05")]; ok {
+				events[i].ReasonID = r.ID
+				events[i].ReasonCode = r.ReasonCode
+				events[i].Comment = r.Comment
+			}
+		}
+
+		totalSeconds := 0.0
+		for _, e := range events {
+			totalSeconds += e.DurationSeconds
+		}
+
+		shifts = append(shifts, gin.H{
+			"shift":              s.No,
+			"start_time":         s.Start,
+			"end_time":           s.End,
+			"stop_count":         len(events),
+			"total_stop_minutes": totalSeconds / 60,
+		})
+		allEvents = append(allEvents, events...)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"line":   line,
+		"date":   baseDate.Format("2006-01-02"),
+		"shifts": shifts,
+		"count":  len(allEvents),
+		"events": allEvents,
+	})
+}
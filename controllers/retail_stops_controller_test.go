@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExtractStopEvents(t *testing.T) {
+	start := time.Date(2026, 1, 5, 8, 0, 0, 0, jakartaLoc)
+	at := func(s int) time.Time { return start.Add(time.Duration(s) * time.Second) }
+	windowEnd := at(3600)
+	afterShift := at(7200)
+
+	tests := []struct {
+		name      string
+		samples   []RetailSample
+		now       time.Time
+		durations []float64
+		open      bool
+	}{
+		{
+			name:      "stop ditutup saat mesin jalan lagi",
+			samples:   append(machineSamples(at(0), at(9), 1), append(machineSamples(at(10), at(39), 0), machineSamples(at(40), at(45), 1)...)...),
+			durations: []float64{30},
+		},
+		{
+			name:    "run, jeda, run bukan stop",
+			samples: append(machineSamples(at(0), at(9), 1), machineSamples(at(300), at(309), 1)...),
+		},
+		{
+			name:      "outage di tengah stop tidak dihitung",
+			samples:   append(machineSamples(at(0), at(19), 0), machineSamples(at(600), at(609), 1)...),
+			durations: []float64{20},
+		},
+		{
+			name:      "stop, jeda, stop jadi dua kejadian",
+			samples:   append(append(machineSamples(at(0), at(9), 0), machineSamples(at(300), at(309), 0)...), RetailSample{Ts: at(310), StartMesin: 1}),
+			durations: []float64{10, 10},
+		},
+		{
+			name:      "stop di akhir data",
+			samples:   append(machineSamples(at(0), at(9), 1), machineSamples(at(10), at(20), 0)...),
+			now:       at(21),
+			durations: []float64{10},
+			open:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := tt.now
+			if now.IsZero() {
+				now = afterShift
+			}
+			events := extractStopEvents(tt.samples, windowEnd, now, 10*time.Second)
+			if len(events) != len(tt.durations) {
+				t.Fatalf("stop = %d, want %d (%+v)", len(events), len(tt.durations), events)
+			}
+			for i, e := range events {
+				if e.DurationSeconds != tt.durations[i] {
+					t.Errorf("stop %d = %v detik, want %v", i, e.DurationSeconds, tt.durations[i])
+				}
+			}
+			if n := len(events); n > 0 && events[n-1].Open != tt.open {
+				t.Errorf("open = %v, want %v", events[n-1].Open, tt.open)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"backend-golang/shiftcal"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Status segment timeline
+const (
+	segmentRunning = "RUNNING"
+	segmentStopped = "STOPPED"
+	segmentNoData  = "NO_DATA"
+)
+
+// Satu segment berurutan dengan status mesin yang sama
+type timelineSegment struct {
+	State           string    `json:"state"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds float64   `json:"duration_seconds"`
+}
+
+// Gabungkan sampel per detik menjadi segment RUNNING/STOPPED.
+// Lama tiap sampel memakai sampleSpan seperti integrateDuration (sampel terakhir berlaku
+// sampai windowEnd), jadi running_minutes sama dengan runtime. Sisa jeda yang melebihi
+// maxGap menjadi NO_DATA.
+func buildTimeline(samples []RetailSample, windowEnd time.Time, maxGap time.Duration) []timelineSegment {
+	var segments []timelineSegment
+
+	push := func(state string, start, end time.Time) {
+		if !end.After(start) {
+			return
+		}
+		if n := len(segments); n > 0 && segments[n-1].State == state && segments[n-1].End.Equal(start) {
+			segments[n-1].End = end
+			return
+		}
+		segments = append(segments, timelineSegment{State: state, Start: start, End: end})
+	}
+
+	for i, sample := range samples {
+		state := segmentStopped
+		if sample.StartMesin == 1 {
+			state = segmentRunning
+		}
+
+		next := windowEnd
+		if i+1 < len(samples) {
+			next = samples[i+1].Ts
+		}
+		end := sample.Ts.Add(sampleSpan(sample.Ts, next, maxGap))
+		push(state, sample.Ts, end)
+		push(segmentNoData, end, next)
+	}
+
+	for i := range segments {
+		segments[i].DurationSeconds = segments[i].End.Sub(segments[i].Start).Seconds()
+	}
+	return segments
+}
+
+// Timeline satu line untuk shift terpilih
+func buildLineTimeline(line string, selected []shiftcal.Shift, now time.Time) (gin.H, error) {
+	shifts := []gin.H{}
+	for _, s := range selected {
+		var segments []timelineSegment
+		if !now.Before(s.Start) {
+			samples, err := getRetailSamples(line, s.Start, s.End)
+			if err != nil {
+				return nil, fmt.Errorf("shift %d: %w", s.No, err)
+			}
+			segments = buildTimeline(samples, integrationEnd(s.End, now), maxSampleGap())
+		}
+
+		totals := map[string]float64{segmentRunning: 0, segmentStopped: 0, segmentNoData: 0}
+		for _, seg := range segments {
+			totals[seg.State] += seg.DurationSeconds
+		}
+
+		shifts = append(shifts, gin.H{
+			"shift":           s.No,
+			"start_time":      s.Start,
+			"end_time":        s.End,
+			"running_minutes": totals[segmentRunning] / 60,
+			"stopped_minutes": totals[segmentStopped] / 60,
+			"no_data_minutes": totals[segmentNoData] / 60,
+			"segments":        segments,
+		})
+	}
+
+	return gin.H{"line": line, "shifts": shifts}, nil
+}
+
+// Controller untuk timeline run/stop satu line
+func RetailTimeline(c *gin.Context) {
+	line := c.Param("line")
+
+	// Validasi line
+	if !isRetailLine(line) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Line %s tidak terdaftar", line)})
+		return
+	}
+
+	baseDate, err := parseRetailDate(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal salah. Gunakan YYYY-MM-DD"})
+		return
+	}
+
+	calendar, err := shiftcal.ForDate(line, baseDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal mengambil kalender shift: %v", err)})
+		return
+	}
+	selected, err := filterShifts(calendar, c.Query("shift"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now().In(jakartaLoc)
+	result, err := buildLineTimeline(line, selected, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal mengambil data: %v", err)})
+		return
+	}
+	result["date"] = baseDate.Format("2006-01-02")
+	result["current_shift"] = getCurrentShift(line, now)
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Controller untuk timeline semua line (Gantt plant)
+func RetailTimelineAll(c *gin.Context) {
+	baseDate, err := parseRetailDate(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal salah. Gunakan YYYY-MM-DD"})
+		return
+	}
+	shiftParam := c.Query("shift")
+
+	now := time.Now().In(jakartaLoc)
+	lines := runPerLine(activeRetailLines(), overviewWorkers, func(line string) (gin.H, error) {
+		calendar, err := shiftcal.ForDate(line, baseDate)
+		if err != nil {
+			return nil, fmt.Errorf("kalender shift: %w", err)
+		}
+		selected, err := filterShifts(calendar, shiftParam)
+		if err != nil {
+			return nil, err
+		}
+		return buildLineTimeline(line, selected, now)
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"date":         baseDate.Format("2006-01-02"),
+		"generated_at": now,
+		"lines":        lines,
+	})
+}
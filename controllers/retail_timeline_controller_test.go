@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildTimelineMatchesRuntime(t *testing.T) {
+	start := time.Date(2026, 1, 5, 8, 0, 0, 0, jakartaLoc)
+	at := func(s int) time.Time { return start.Add(time.Duration(s) * time.Second) }
+	maxGap := 10 * time.Second
+
+	tests := []struct {
+		name      string
+		samples   []RetailSample
+		windowEnd time.Time
+		running   float64
+		noData    float64
+	}{
+		{name: "sampel terakhir berlaku sampai akhir window", samples: machineSamples(at(0), at(9), 1), windowEnd: at(15), running: 15},
+		{name: "jeda panjang jadi NO_DATA", samples: append(machineSamples(at(0), at(9), 1), machineSamples(at(100), at(104), 1)...), windowEnd: at(105), running: 15, noData: 90},
+		{name: "akhir window jauh setelah data", samples: machineSamples(at(0), at(4), 1), windowEnd: at(60), running: 5, noData: 55},
+		{name: "run dan stop", samples: append(machineSamples(at(0), at(4), 1), machineSamples(at(5), at(9), 0)...), windowEnd: at(12), running: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			totals := map[string]float64{}
+			for _, seg := range buildTimeline(tt.samples, tt.windowEnd, maxGap) {
+				totals[seg.State] += seg.DurationSeconds
+			}
+
+			times := make([]time.Time, len(tt.samples))
+			for i, s := range tt.samples {
+				times[i] = s.Ts
+			}
+			runtime := integrateDuration(times, func(i int) bool { return tt.samples[i].StartMesin == 1 }, tt.windowEnd, maxGap)
+
+			if totals[segmentRunning] != runtime.Seconds() {
+				t.Errorf("running timeline = %v, runtime = %v", totals[segmentRunning], runtime.Seconds())
+			}
+			if totals[segmentRunning] != tt.running || totals[segmentNoData] != tt.noData {
+				t.Errorf("running/no data = %v/%v, want %v/%v", totals[segmentRunning], totals[segmentNoData], tt.running, tt.noData)
+			}
+		})
+	}
+}
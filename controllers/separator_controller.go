@@ -9,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"backend-golang/config"
 	"backend-golang/models"
+	"backend-golang/shiftcal"
 )
 
 // Response structures
@@ -60,6 +61,21 @@ func getSeparatorActionAndStatus(value int) (string, string) {
 	return "close", "danger"       // 0 = CLOSED = danger (red)
 }
 
+// Ambil nilai separator ke-id (1-4) dari satu baris data
+func separatorValue(row models.SeparatorSensor, id int) int {
+	switch id {
+	case 1:
+		return row.Separator1
+	case 2:
+		return row.Separator2
+	case 3:
+		return row.Separator3
+	case 4:
+		return row.Separator4
+	}
+	return 0
+}
+
 func isValidSeparatorValue(value int) bool {
 	return value == 0 || value == 1
 }
@@ -122,9 +138,19 @@ func GetSeparatorHistoryByDate(c *gin.Context) {
 	fmt.Printf("Base date: %v\n", baseDate)
 	fmt.Printf("Base date timezone: %v\n", baseDate.Location())
 
-	// Rentang waktu operasional: 06:00 hari ini - 05:59:59 besok (dalam WIB)
-	startTime := time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day(), 6, 0, 0, 0, loc)
-	endTime := time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day()+1, 5, 59, 59, 0, loc)
+	// Ambil shift dari kalender shift
+	calendar, err := shiftcal.ForDate("separator", baseDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success":   false,
+			"message":   fmt.Sprintf("Gagal mengambil kalender shift: %v", err),
+			"timestamp": time.Now().In(loc),
+		})
+		return
+	}
+
+	// Rentang waktu operasional: awal shift pertama - akhir shift terakhir (dalam WIB)
+	startTime, endTime := shiftcal.DayRange(calendar, baseDate)
 
 	fmt.Printf("Start time: %v (UTC: %v)\n", startTime, startTime.UTC())
 	fmt.Printf("End time: %v (UTC: %v)\n", endTime, endTime.UTC())
@@ -133,22 +159,6 @@ func GetSeparatorHistoryByDate(c *gin.Context) {
 	startTimeUTC := startTime.UTC()
 	endTimeUTC := endTime.UTC()
 
-	// Definisi shift dalam WIB
-	shifts := map[string][2]time.Time{
-		"shift1": {
-			time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day(), 6, 0, 0, 0, loc),
-			time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day(), 14, 0, 0, 0, loc),
-		},
-		"shift2": {
-			time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day(), 14, 0, 1, 0, loc),
-			time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day(), 22, 0, 0, 0, loc),
-		},
-		"shift3": {
-			time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day(), 22, 0, 1, 0, loc),
-			time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day()+1, 5, 59, 59, 0, loc),
-		},
-	}
-
 	// Struct untuk response data
 	type EnrichedSeparatorSensor struct {
 		Waktu      time.Time `json:"Waktu"`
@@ -170,15 +180,16 @@ func GetSeparatorHistoryByDate(c *gin.Context) {
 	fmt.Printf("Total records found: %d\n", totalRecords)
 
 	// Ambil dan gabungkan data per shift
-	for shiftName, timeRange := range shifts {
+	for _, shift := range calendar {
 		var shiftHistory []models.SeparatorSensor
+		shiftName := fmt.Sprintf("shift%d", shift.No)
 
 		// Convert shift time range to UTC for database query
-		shiftStartUTC := timeRange[0].UTC()
-		shiftEndUTC := timeRange[1].UTC()
+		shiftStartUTC := shift.Start.UTC()
+		shiftEndUTC := shift.End.UTC()
 
 		fmt.Printf("Shift %s: %v - %v (UTC: %v - %v)\n", 
-			shiftName, timeRange[0], timeRange[1], shiftStartUTC, shiftEndUTC)
+			shiftName, shift.Start, shift.End, shiftStartUTC, shiftEndUTC)
 
 		shiftResult := config.DB.
 			Where("waktu BETWEEN ? AND ?", shiftStartUTC, shiftEndUTC).
@@ -425,78 +436,66 @@ func GetSeparatorStatus(c *gin.Context) {
 		4: statusText(latest.Separator4),
 	}
 
-	// Definisi shift
-	today := now.Format("2006-01-02")
-	tomorrow := now.Add(24 * time.Hour).Format("2006-01-02")
-	shifts := map[string][2]string{
-		"shift1": {today + " 06:00:00", today + " 14:00:00"},
-		"shift2": {today + " 14:00:01", today + " 22:00:00"},
-		"shift3": {today + " 22:00:01", tomorrow + " 05:59:59"},
+	// Definisi shift dari kalender shift
+	calendar, err := shiftcal.ForDate("separator", now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal ambil kalender shift"})
+		return
 	}
 
 	// Struktur hasil
 	type ShiftStat struct {
-		Duration int64 `json:"duration"` // jumlah data point bernilai 1
+		Duration int64 `json:"duration"` // detik bernilai 1, dari selisih timestamp
 		Count    int64 `json:"count"`    // jumlah blok aktif bernilai 1
 	}
 	result := make(map[int]map[string]ShiftStat)
 	for sepID := 1; sepID <= 4; sepID++ {
-		result[sepID] = map[string]ShiftStat{
-			"shift1": {},
-			"shift2": {},
-			"shift3": {},
+		result[sepID] = map[string]ShiftStat{}
+		for _, shift := range calendar {
+			result[sepID][fmt.Sprintf("shift%d", shift.No)] = ShiftStat{}
 		}
 	}
 
 	// Proses per shift
-	for shiftName, timeRange := range shifts {
-		query := fmt.Sprintf(`
+	for _, shift := range calendar {
+		shiftName := fmt.Sprintf("shift%d", shift.No)
+		query := `
 			SELECT waktu, separator1, separator2, separator3, separator4
 			FROM readsensors_separator
-			WHERE waktu BETWEEN '%s' AND '%s'
+			WHERE waktu BETWEEN ? AND ?
 			ORDER BY waktu ASC
-		`, timeRange[0], timeRange[1])
+		`
 
 		var rows []models.SeparatorSensor
-		if err := config.DB.Raw(query).Scan(&rows).Error; err != nil {
+		if err := config.DB.Raw(query, shift.Start.Format("2006-01-02 15:04:05"), shift.End.Format("2006-01-02 15:04:05")).Scan(&rows).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal ambil data shift"})
 			return
 		}
 
-		// Hitung duration dan count berdasarkan blok aktif
-		activePeriods := make(map[int]int)     // separator -> jumlah blok aktif
-		inActive := make(map[int]bool)         // separator -> sedang dalam blok aktif
+		// Count = jumlah blok aktif, duration = integrasi selisih waktu antar sampel saat bernilai 1
+		times := make([]time.Time, len(rows))
+		for i, row := range rows {
+			times[i] = toJakartaWall(row.Waktu)
+		}
+		windowEnd := integrationEnd(shift.End, now)
 
-		for _, row := range rows {
-			separators := map[int]int{
-				1: row.Separator1,
-				2: row.Separator2,
-				3: row.Separator3,
-				4: row.Separator4,
+		for sepID := 1; sepID <= 4; sepID++ {
+			values := make([]int, len(rows))
+			for i, row := range rows {
+				values[i] = separatorValue(row, sepID)
 			}
-			for sepID, val := range separators {
-				if val == 1 {
-					// Tambah durasi
-					result[sepID][shiftName] = ShiftStat{
-						Duration: result[sepID][shiftName].Duration + 1,
-						Count:    result[sepID][shiftName].Count,
-					}
-					// Jika baru mulai blok aktif
-					if !inActive[sepID] {
-						activePeriods[sepID]++
-						inActive[sepID] = true
-					}
-				} else {
-					inActive[sepID] = false
+
+			var count int64
+			for i, val := range values {
+				if val == 1 && (i == 0 || values[i-1] != 1) {
+					count++
 				}
 			}
-		}
+			open := integrateDuration(times, func(i int) bool { return values[i] == 1 }, windowEnd, maxSampleGap())
 
-		// Finalisasi count
-		for sepID := 1; sepID <= 4; sepID++ {
 			result[sepID][shiftName] = ShiftStat{
-				Duration: result[sepID][shiftName].Duration,
-				Count:    int64(activePeriods[sepID]),
+				Duration: int64(open.Seconds()),
+				Count:    count,
 			}
 		}
 	}
@@ -535,41 +534,48 @@ func GetSeparatorSensorByShift(c *gin.Context) {
 		baseDate = time.Now().In(loc)
 	}
 
-	// Tentukan shift aktif jika tidak ada parameter shift
-	var shift int
+	// Tentukan shift dari kalender, pakai shift aktif jika tidak ada parameter shift
+	var selected shiftcal.Shift
 	if shiftParam != "" {
-		shift, err = strconv.Atoi(shiftParam)
-		if err != nil || shift < 1 || shift > 3 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Shift harus bernilai 1, 2, atau 3"})
+		shift, err := strconv.Atoi(shiftParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Shift harus berupa angka"})
+			return
+		}
+		found, ok, err := shiftcal.Find("separator", baseDate, shift)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil kalender shift"})
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Shift %d tidak ada pada tanggal %s", shift, baseDate.Format("2006-01-02"))})
 			return
 		}
+		selected = found
 	} else {
+		// Di luar jam shift dipakai shift terakhir yang sudah selesai
 		now := time.Now().In(loc)
-		currentTime := now.Hour()*3600 + now.Minute()*60 + now.Second()
-
-		switch {
-		case currentTime >= 6*3600 && currentTime <= 14*3600:
-			shift = 1
-		case currentTime > 14*3600 && currentTime <= 22*3600:
-			shift = 2
-		default:
-			shift = 3
+		found, ok, err := shiftcal.Latest("separator", now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil kalender shift"})
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Tidak ada shift yang sedang berjalan"})
+			return
+		}
+		selected = found
+		if dateParam == "" {
+			baseDate, _ = time.ParseInLocation("2006-01-02", found.Date, loc)
+		} else if onDate, ok, err := shiftcal.Find("separator", baseDate, found.No); err == nil && ok {
+			// Nomor shift aktif, diterapkan pada tanggal yang diminta
+			selected = onDate
 		}
 	}
 
 	// Hitung waktu mulai dan akhir berdasarkan shift
-	var startTime, endTime string
-	switch shift {
-	case 1:
-		startTime = baseDate.Format("2006-01-02") + " 06:00:00"
-		endTime = baseDate.Format("2006-01-02") + " 14:00:00"
-	case 2:
-		startTime = baseDate.Format("2006-01-02") + " 14:00:01"
-		endTime = baseDate.Format("2006-01-02") + " 22:00:00"
-	case 3:
-		startTime = baseDate.Format("2006-01-02") + " 22:00:01"
-		endTime = baseDate.AddDate(0, 0, 1).Format("2006-01-02") + " 05:59:59"
-	}
+	startTime := selected.Start.Format("2006-01-02 15:04:05")
+	endTime := selected.End.Format("2006-01-02 15:04:05")
 
 	query := `
 		SELECT waktu, separator1, separator2, separator3, separator4
@@ -605,7 +611,7 @@ func GetSeparatorSensorByShift(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"tanggal": baseDate.Format("2006-01-02"),
-		"shift":   shift,
+		"shift":   selected.No,
 		"data":    results,
 	})
 }
@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"backend-golang/config"
+	"backend-golang/models"
+	"backend-golang/shiftcal"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetShiftPatterns -> daftar pola shift, bisa filter line dan day_type
+func GetShiftPatterns(c *gin.Context) {
+	var patterns []models.ShiftPattern
+
+	query := config.DB.Order("line ASC, day_type ASC, shift_no ASC")
+	if line := c.Query("line"); line != "" {
+		query = query.Where("line = ?", strings.ToLower(line))
+	}
+	if dayType := c.Query("day_type"); dayType != "" {
+		query = query.Where("day_type = ?", dayType)
+	}
+
+	if err := query.Find(&patterns).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal mengambil pola shift",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(patterns),
+		"data":    patterns,
+	})
+}
+
+// CreateShiftPattern -> tambah pola shift baru
+func CreateShiftPattern(c *gin.Context) {
+	var pattern models.ShiftPattern
+	if err := c.ShouldBindJSON(&pattern); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Body tidak valid", "error": err.Error()})
+		return
+	}
+	pattern.ID = 0
+	pattern.Line = strings.ToLower(pattern.Line)
+
+	if err := shiftcal.ValidatePattern(pattern); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	if err := config.DB.Create(&pattern).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan pola shift", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": pattern})
+}
+
+// UpdateShiftPattern -> ubah pola shift berdasarkan id
+func UpdateShiftPattern(c *gin.Context) {
+	var pattern models.ShiftPattern
+	if err := config.DB.First(&pattern, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Pola shift tidak ditemukan"})
+		return
+	}
+
+	var input models.ShiftPattern
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Body tidak valid", "error": err.Error()})
+		return
+	}
+	input.ID = pattern.ID
+	input.CreatedAt = pattern.CreatedAt
+	input.Line = strings.ToLower(input.Line)
+
+	if err := shiftcal.ValidatePattern(input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	if err := config.DB.Save(&input).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengubah pola shift", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": input})
+}
+
+// DeleteShiftPattern -> hapus pola shift, kalender kembali ke pola global/bawaan
+func DeleteShiftPattern(c *gin.Context) {
+	result := config.DB.Delete(&models.ShiftPattern{}, c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menghapus pola shift", "error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Pola shift tidak ditemukan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Pola shift dihapus"})
+}
+
+// GetShiftHolidays -> daftar hari libur, bisa filter tahun
+func GetShiftHolidays(c *gin.Context) {
+	var holidays []models.ShiftHoliday
+
+	query := config.DB.Order("date ASC")
+	if year := c.Query("year"); year != "" {
+		query = query.Where("date LIKE ?", year+"-%")
+	}
+
+	if err := query.Find(&holidays).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil hari libur", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(holidays),
+		"data":    holidays,
+	})
+}
+
+// CreateShiftHoliday -> tambah hari libur (line kosong = semua line)
+func CreateShiftHoliday(c *gin.Context) {
+	var holiday models.ShiftHoliday
+	if err := c.ShouldBindJSON(&holiday); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Body tidak valid", "error": err.Error()})
+		return
+	}
+	holiday.ID = 0
+	holiday.Line = strings.ToLower(holiday.Line)
+
+	if _, err := time.Parse("2006-01-02", holiday.Date); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format tanggal salah. Gunakan YYYY-MM-DD"})
+		return
+	}
+
+	if err := config.DB.Create(&holiday).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan hari libur", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": holiday})
+}
+
+// DeleteShiftHoliday -> hapus hari libur
+func DeleteShiftHoliday(c *gin.Context) {
+	result := config.DB.Delete(&models.ShiftHoliday{}, c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menghapus hari libur", "error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Hari libur tidak ditemukan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Hari libur dihapus"})
+}
+
+// GetShiftCalendar -> hasil resolve kalender shift untuk line dan tanggal tertentu
+func GetShiftCalendar(c *gin.Context) {
+	line := c.Query("line")
+
+	baseDate, err := parseRetailDate(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format tanggal salah. Gunakan YYYY-MM-DD"})
+		return
+	}
+
+	shifts, err := shiftcal.ForDate(line, baseDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": fmt.Sprintf("Gagal mengambil kalender shift: %v", err)})
+		return
+	}
+
+	dayType := ""
+	if len(shifts) > 0 {
+		dayType = shifts[0].DayType
+	} else if dayType, err = shiftcal.DayTypeOf(line, baseDate); err != nil {
+		dayType = ""
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"line":     line,
+		"date":     baseDate.Format("2006-01-02"),
+		"day_type": dayType,
+		"data":     shifts,
+	})
+}
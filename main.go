@@ -2,6 +2,8 @@ package main
 
 import (
 	"backend-golang/config"
+	"backend-golang/controllers"
+	"backend-golang/models"
 	"backend-golang/routes"
 	"log"
 	"net/http"
@@ -31,6 +33,35 @@ func main() {
     godotenv.Load()
     config.ConnectDB()
 
+    // Migrasi tabel konfigurasi (tabel data sensor dikelola collector)
+    if err := config.DB.AutoMigrate(
+        &models.ShiftPattern{},
+        &models.ShiftHoliday{},
+        &models.DowntimeReason{},
+        &models.StopReason{},
+        &models.ProductionTarget{},
+        &models.ProductionRun{},
+        &models.RetailLine{},
+        &models.AlarmLimit{},
+        &models.HTSTConfig{},
+        &models.HTSTSignoff{},
+        &models.TankEvent{},
+    ); err != nil {
+        log.Fatal("Failed to migrate database:", err)
+    }
+    if err := models.SeedRetailLines(config.DB); err != nil {
+        log.Println("Failed to seed retail lines:", err)
+    }
+    if err := models.SeedDowntimeReasons(config.DB); err != nil {
+        log.Println("Failed to seed downtime reasons:", err)
+    }
+    if err := models.SeedAlarmLimits(config.DB); err != nil {
+        log.Println("Failed to seed alarm limits:", err)
+    }
+    if err := models.SeedHTSTConfig(config.DB); err != nil {
+        log.Println("Failed to seed HTST config:", err)
+    }
+
     r := gin.Default()
     r.Use(CORSMiddleware())
 
@@ -45,6 +76,11 @@ func main() {
     routes.RegisterRetailRoutes(r)
     routes.RegisterSeparatorRoutes(r) 
     routes.RegisterPasteurRoutes(r) 
+    routes.RegisterShiftRoutes(r)
+    routes.RegisterDataQualityRoutes(r)
+
+    // Rekam event overflow/run-dry tank pasteur di background
+    controllers.StartTankEventRecorder()
 
     log.Println("Server running on 0.0.0.0:8080")
     if err := r.Run("0.0.0.0:8080"); err != nil {
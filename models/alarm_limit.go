@@ -0,0 +1,92 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Batas alarm satu parameter pasteurisasi. Field memakai nama JSON SensorPasteurisasi
+// (misal suhu_holding), batas kosong (null) berarti tidak dicek.
+type AlarmLimit struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Field       string         `json:"field" gorm:"column:field;size:50;uniqueIndex"`
+	LL          *float64       `json:"ll" gorm:"column:ll"`
+	L           *float64       `json:"l" gorm:"column:l"`
+	H           *float64       `json:"h" gorm:"column:h"`
+	HH          *float64       `json:"hh" gorm:"column:hh"`
+	Unit        string         `json:"unit" gorm:"column:unit;size:20"`
+	Description string         `json:"description" gorm:"column:description;size:255"`
+	Active      bool           `json:"active" gorm:"column:active"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"` // soft delete supaya batas yang dihapus tidak diisi ulang seed
+}
+
+func (AlarmLimit) TableName() string { return "pasteur_alarm_limits" }
+
+func limitValue(v float64) *float64 { return &v }
+
+// Batas bawaan. Heating dan holding mengikuti batas lama yang dulu di-hardcode di
+// GetPasteurAbnormal (105-120) sebagai warning L/H, ditambah LL/HH 5 °C di luarnya
+// sebagai critical, dan langsung aktif. Parameter lain hanya contoh nilai
+// awal yang belum dikonfirmasi dengan spesifikasi mesin, jadi diisi nonaktif dan baru
+// dicek setelah dikonfirmasi dan diaktifkan lewat API. level_vd hanya L/H, LL/HH
+// diambil dari setpoint VDLL/VDHH.
+var defaultAlarmLimits = []AlarmLimit{
+	{Field: "suhu_heating", LL: limitValue(100), L: limitValue(105), H: limitValue(120), HH: limitValue(125), Unit: "°C", Active: true},
+	{Field: "suhu_holding", LL: limitValue(100), L: limitValue(105), H: limitValue(120), HH: limitValue(125), Unit: "°C", Active: true},
+	{Field: "suhu_preheating", H: limitValue(85), HH: limitValue(95), Unit: "°C", Active: false},
+	{Field: "suhu_precooling", H: limitValue(30), HH: limitValue(40), Unit: "°C", Active: false},
+	{Field: "suhu_cooling", H: limitValue(6), HH: limitValue(10), Unit: "°C", Active: false},
+	{Field: "pressure_mixing", H: limitValue(4), HH: limitValue(5), Unit: "bar", Active: false},
+	{Field: "pressure_bt2", H: limitValue(4), HH: limitValue(5), Unit: "bar", Active: false},
+	{Field: "press_to_pasteur", LL: limitValue(0.5), L: limitValue(1), H: limitValue(5), HH: limitValue(6), Unit: "bar", Active: false},
+	{Field: "level_bt1", LL: limitValue(5), L: limitValue(10), H: limitValue(90), HH: limitValue(95), Unit: "%", Active: false},
+	{Field: "level_bt2", LL: limitValue(5), L: limitValue(10), H: limitValue(90), HH: limitValue(95), Unit: "%", Active: false},
+	{Field: "level_vd", L: limitValue(10), H: limitValue(90), Unit: "%", Active: false},
+	{Field: "speed_pompa_mixing", H: limitValue(90), HH: limitValue(98), Unit: "%", Active: false},
+	{Field: "speed_pump_bt1", H: limitValue(90), HH: limitValue(98), Unit: "%", Active: false},
+	{Field: "speed_pump_bt2", H: limitValue(90), HH: limitValue(98), Unit: "%", Active: false},
+	{Field: "speed_pump_vd", H: limitValue(90), HH: limitValue(98), Unit: "%", Active: false},
+}
+
+// Isi batas alarm bawaan untuk field yang belum pernah punya baris. Baris yang
+// sudah dihapus (soft delete) ikut dihitung supaya tidak terisi ulang saat restart.
+func SeedAlarmLimits(db *gorm.DB) error {
+	var existing []string
+	if err := db.Unscoped().Model(&AlarmLimit{}).Pluck("field", &existing).Error; err != nil {
+		return err
+	}
+	have := make(map[string]bool, len(existing))
+	for _, f := range existing {
+		have[f] = true
+	}
+
+	var limits []AlarmLimit
+	for _, l := range defaultAlarmLimits {
+		if !have[l.Field] {
+			limits = append(limits, l)
+		}
+	}
+	if len(limits) > 0 {
+		if err := db.Create(&limits).Error; err != nil {
+			return err
+		}
+	}
+
+	// Baris suhu dari seed lama (hanya L/H 105-120, belum pernah diisi LL/HH) dilengkapi
+	// LL/HH bawaan. Baris yang sudah diubah operator tidak disentuh.
+	for _, l := range defaultAlarmLimits {
+		if l.Field != "suhu_heating" && l.Field != "suhu_holding" {
+			continue
+		}
+		err := db.Model(&AlarmLimit{}).
+			Where("field = ? AND l = ? AND h = ? AND ll IS NULL AND hh IS NULL", l.Field, *l.L, *l.H).
+			Updates(map[string]interface{}{"ll": *l.LL, "hh": *l.HH}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Katalog alasan downtime. Planned = stop terencana (changeover, cleaning, dll).
+type DowntimeReason struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Code      string    `json:"code" gorm:"column:code;size:30;uniqueIndex"`
+	Name      string    `json:"name" gorm:"column:name;size:100"`
+	Category  string    `json:"category" gorm:"column:category;size:50"`
+	Planned   bool      `json:"planned" gorm:"column:planned"`
+	Active    bool      `json:"active" gorm:"column:active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (DowntimeReason) TableName() string { return "downtime_reasons" }
+
+// Alasan yang diberikan supervisor untuk satu kejadian stop di line retail.
+// Stop diidentifikasi dengan line + waktu mulai (WIB, format YYYY-MM-DD HH:MM:SS).
+type StopReason struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	Line            string    `json:"line" gorm:"column:line;size:20;uniqueIndex:idx_stop_reason_line_start"`
+	StopStart       string    `json:"stop_start" gorm:"column:stop_start;size:19;uniqueIndex:idx_stop_reason_line_start"`
+	StopEnd         string    `json:"stop_end" gorm:"column:stop_end;size:19"`
+	DurationSeconds float64   `json:"duration_seconds" gorm:"column:duration_seconds"`
+	Date            string    `json:"date" gorm:"column:date;size:10;index"` // tanggal produksi
+	Shift           int       `json:"shift" gorm:"column:shift"`
+	ReasonCode      string    `json:"reason_code" gorm:"column:reason_code;size:30;index"`
+	Comment         string    `json:"comment" gorm:"column:comment;type:text"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (StopReason) TableName() string { return "stop_reasons" }
+
+// Alasan bawaan yang diisi saat katalog masih kosong
+var defaultDowntimeReasons = []DowntimeReason{
+	{Code: "MATERIAL", Name: "Material shortage", Category: "Material", Planned: false, Active: true},
+	{Code: "CHANGEOVER", Name: "Changeover", Category: "Setup", Planned: true, Active: true},
+	{Code: "BREAKDOWN", Name: "Breakdown", Category: "Maintenance", Planned: false, Active: true},
+	{Code: "CLEANING", Name: "Cleaning", Category: "Sanitasi", Planned: true, Active: true},
+}
+
+// SeedDowntimeReasons isi katalog alasan bawaan jika tabel masih kosong
+func SeedDowntimeReasons(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&DowntimeReason{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	reasons := append([]DowntimeReason(nil), defaultDowntimeReasons...)
+	return db.Create(&reasons).Error
+}
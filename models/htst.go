@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Cara membaca tag Time_Divert. Arti tag ini belum terdokumentasi di PLC (bisa status
+// valve, timer, atau setpoint delay), jadi mode dan threshold wajib dikonfirmasi dengan
+// tim otomasi sebelum laporan HTST dipakai sebagai bukti audit.
+const (
+	DivertSignalLevel   = "level"   // Time_Divert > DivertThreshold berarti valve di posisi divert
+	DivertSignalCounter = "counter" // Time_Divert timer yang naik lebih dari DivertThreshold per sampel selama divert
+)
+
+// Parameter compliance HTST. Hanya satu baris (id = 1) yang dipakai.
+type HTSTConfig struct {
+	ID                    uint      `json:"id" gorm:"primaryKey"`
+	MinHoldingTemp        float64   `json:"min_holding_temp" gorm:"column:min_holding_temp"`                 // °C, SuhuHolding minimal
+	MinHoldingSeconds     float64   `json:"min_holding_seconds" gorm:"column:min_holding_seconds"`           // waktu tahan minimal di holding tube
+	HoldingTubeLiters     float64   `json:"holding_tube_liters" gorm:"column:holding_tube_liters"`           // volume holding tube, 0 = waktu tahan tidak dicek
+	MinProductionFlowrate float64   `json:"min_production_flowrate" gorm:"column:min_production_flowrate"`   // flowrate (L/jam) minimal dianggap produksi
+	DivertReactionSeconds float64   `json:"divert_reaction_seconds" gorm:"column:divert_reaction_seconds"`   // batas waktu divert bereaksi
+	DivertSignal          string    `json:"divert_signal" gorm:"column:divert_signal;size:20;default:level"` // level | counter
+	DivertThreshold       float64   `json:"divert_threshold" gorm:"column:divert_threshold"`
+	UpdatedBy             string    `json:"updated_by" gorm:"column:updated_by;size:100"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
+func (HTSTConfig) TableName() string { return "htst_configs" }
+
+// Tanda tangan ringkasan compliance harian. Summary menyimpan snapshot JSON
+// hasil perhitungan (termasuk konfigurasi) saat ditandatangani.
+type HTSTSignoff struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Date         string    `json:"date" gorm:"column:date;size:10;uniqueIndex"` // YYYY-MM-DD
+	Compliant    bool      `json:"compliant" gorm:"column:compliant"`
+	Status       string    `json:"status" gorm:"column:status;size:20"` // compliant | non_compliant | unverifiable
+	SignedBy     string    `json:"signed_by" gorm:"column:signed_by;size:100"`
+	Note         string    `json:"note" gorm:"column:note;type:text"`
+	OverrideNote string    `json:"override_note" gorm:"column:override_note;type:text"` // alasan sign-off hari unverifiable
+	Summary      string    `json:"summary" gorm:"column:summary;type:longtext"`
+	SignedAt     time.Time `json:"signed_at" gorm:"column:signed_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (HTSTSignoff) TableName() string { return "htst_signoffs" }
+
+// Isi konfigurasi bawaan jika belum ada, suhu minimal mengikuti batas lama 105°C
+func SeedHTSTConfig(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&HTSTConfig{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return db.Create(&HTSTConfig{
+		ID:                    1,
+		MinHoldingTemp:        105,
+		MinHoldingSeconds:     15,
+		MinProductionFlowrate: 1,
+		DivertReactionSeconds: 5,
+		DivertSignal:          DivertSignalLevel,
+	}).Error
+}
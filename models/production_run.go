@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Satu periode produksi satu produk (SKU) di line retail.
+// Start/End disimpan dalam WIB format YYYY-MM-DD HH:MM:SS, End kosong berarti run masih berjalan.
+type ProductionRun struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Line      string    `json:"line" gorm:"column:line;size:20;index"`
+	Product   string    `json:"product" gorm:"column:product;size:50"`
+	Start     string    `json:"start" gorm:"column:start_time;size:19;index"`
+	End       string    `json:"end" gorm:"column:end_time;size:19"`
+	Source    string    `json:"source" gorm:"column:source;size:20"` // manual atau inferred
+	Note      string    `json:"note" gorm:"column:note;size:255"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (ProductionRun) TableName() string { return "production_runs" }
@@ -1,12 +0,0 @@
-package models
-
-import "time"
-
-type RetailD14 struct {
-    ID         uint      `gorm:"primaryKey"`
-    Ts         time.Time `gorm:"column:ts"`
-    StartMesin int       `gorm:"column:start_mesin"`
-    TotalCounter int       `gorm:"column:total_counter"`
-    MainSpeed int       `gorm:"column:main_speed"`
-}
-func (RetailD14) TableName() string  { return "retail_d14" }
\ No newline at end of file
@@ -1,12 +0,0 @@
-package models
-
-import "time"
-
-type RetailD7 struct {
-    ID         uint      `gorm:"primaryKey"`
-    Ts         time.Time `gorm:"column:ts"`
-    StartMesin int       `gorm:"column:start_mesin"`
-    TotalCounter int       `gorm:"column:total_counter"`
-    MainSpeed int       `gorm:"column:main_speed"`
-}
-func (RetailD7) TableName() string  { return "retail_d7" }
\ No newline at end of file
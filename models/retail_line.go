@@ -0,0 +1,73 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Registry line filling retail. Menambah line baru cukup dengan menambah baris di sini,
+// data sensornya dibaca dari DataTable memakai model generik RetailRecord.
+type RetailLine struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	Line            string    `json:"line" gorm:"column:line;size:20;uniqueIndex"`
+	DataTable       string    `json:"table_name" gorm:"column:data_table;size:64"`
+	DisplayName     string    `json:"display_name" gorm:"column:display_name;size:100"`
+	Area            string    `json:"area" gorm:"column:area;size:50"`
+	RatedSpeed      float64   `json:"rated_speed" gorm:"column:rated_speed"` // pack/menit per head
+	HeadsPerMachine int       `json:"heads_per_machine" gorm:"column:heads_per_machine"`
+	CounterRollover int64     `json:"counter_rollover" gorm:"column:counter_rollover"` // nilai maksimal total_counter di PLC, 0 = rollover tidak dideteksi
+	SortOrder       int       `json:"sort_order" gorm:"column:sort_order"`
+	Active          bool      `json:"active" gorm:"column:active"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (RetailLine) TableName() string { return "retail_lines" }
+
+// Satu baris data per detik dari tabel retail_dN mana pun.
+// Tidak punya TableName, gunakan scope RetailTable untuk memilih tabel.
+type RetailRecord struct {
+	ID           uint      `gorm:"primaryKey"`
+	Ts           time.Time `gorm:"column:ts"`
+	StartMesin   int       `gorm:"column:start_mesin"`
+	TotalCounter int       `gorm:"column:total_counter"`
+	MainSpeed    int       `gorm:"column:main_speed"`
+}
+
+// Scope untuk query RetailRecord ke tabel data line tertentu
+func RetailTable(table string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Table(table)
+	}
+}
+
+// Line bawaan sebelum registry ada (dulu model retail_d1..retail_d14)
+var defaultRetailLines = []string{"d1", "d2", "d3", "d4", "d5", "d6", "d7", "d8", "d9", "d10", "d14"}
+
+// Isi registry dengan line bawaan jika tabel masih kosong
+func SeedRetailLines(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&RetailLine{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	lines := make([]RetailLine, 0, len(defaultRetailLines))
+	for i, line := range defaultRetailLines {
+		lines = append(lines, RetailLine{
+			Line:            line,
+			DataTable:       "retail_" + line,
+			DisplayName:     fmt.Sprintf("Line %s", line),
+			Area:            "Retail",
+			RatedSpeed:      40,
+			HeadsPerMachine: 2,
+			SortOrder:       i + 1,
+			Active:          true,
+		})
+	}
+	return db.Create(&lines).Error
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Pola shift per jenis hari. Line kosong berarti berlaku untuk semua line,
+// line terisi (misal "d5" atau "separator") menggantikan pola global untuk line tersebut.
+type ShiftPattern struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	Line           string    `json:"line" gorm:"column:line;size:20;index"`
+	DayType        string    `json:"day_type" gorm:"column:day_type;size:20;index"` // weekday, saturday, sunday, holiday
+	ShiftNo        int       `json:"shift_no" gorm:"column:shift_no"`
+	StartTime      string    `json:"start_time" gorm:"column:start_time;size:8"` // HH:MM:SS
+	EndTime        string    `json:"end_time" gorm:"column:end_time;size:8"`     // HH:MM:SS, lebih kecil dari start = lewat tengah malam
+	PlannedMinutes int       `json:"planned_minutes" gorm:"column:planned_minutes"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (ShiftPattern) TableName() string { return "shift_patterns" }
+
+// Hari libur nasional / libur khusus line. Line kosong berarti libur untuk semua line.
+type ShiftHoliday struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Date      string    `json:"date" gorm:"column:date;size:10;index"` // YYYY-MM-DD
+	Line      string    `json:"line" gorm:"column:line;size:20"`
+	Name      string    `json:"name" gorm:"column:name;size:100"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (ShiftHoliday) TableName() string { return "shift_holidays" }
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Kejadian overflow (level di atas HH) atau run-dry (level di bawah LL) satu tank
+// pasteurisasi. Event diidentifikasi dengan tank + tipe + waktu mulai (WIB, YYYY-MM-DD HH:MM:SS).
+type TankEvent struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	Tank            string    `json:"tank" gorm:"column:tank;size:10;uniqueIndex:idx_tank_event_start"`
+	Type            string    `json:"type" gorm:"column:type;size:20;uniqueIndex:idx_tank_event_start"` // overflow | run_dry
+	Start           string    `json:"start" gorm:"column:start;size:19;uniqueIndex:idx_tank_event_start"`
+	End             string    `json:"end" gorm:"column:end;size:19"`
+	DurationSeconds float64   `json:"duration_seconds" gorm:"column:duration_seconds"`
+	Peak            float64   `json:"peak" gorm:"column:peak"`           // level tertinggi (overflow) atau terendah (run_dry)
+	Threshold       float64   `json:"threshold" gorm:"column:threshold"` // batas HH/LL saat event
+	Date            string    `json:"date" gorm:"column:date;size:10;index"`
+	Ongoing         bool      `json:"ongoing" gorm:"column:ongoing"` // event masih berlangsung saat terakhir direkam
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (TankEvent) TableName() string { return "pasteur_tank_events" }
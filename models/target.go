@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Target produksi per line dan produk, berlaku mulai EffectiveDate sampai ada target yang lebih baru.
+// Product kosong berarti target default line.
+type ProductionTarget struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	Line            string    `json:"line" gorm:"column:line;size:20;index"`
+	Product         string    `json:"product" gorm:"column:product;size:50"`
+	EffectiveDate   string    `json:"effective_date" gorm:"column:effective_date;size:10"` // YYYY-MM-DD
+	RatedSpeed      float64   `json:"rated_speed" gorm:"column:rated_speed"`               // pack/menit per head
+	HeadsPerMachine int       `json:"heads_per_machine" gorm:"column:heads_per_machine"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (ProductionTarget) TableName() string { return "production_targets" }
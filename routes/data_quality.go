@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"backend-golang/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterDataQualityRoutes(r *gin.Engine) {
+	api := r.Group("/api/data-quality")
+	{
+		api.GET("/completeness", controllers.GetDataCompleteness)
+	}
+}
@@ -12,6 +12,19 @@ func RegisterPasteurRoutes(r *gin.Engine) {
 		api.GET("/latest", controllers.GetLatestPasteurData)
 		api.GET("/by-hour", controllers.GetPasteurDataPerHour)
 		api.GET("/abnormal", controllers.GetPasteurAbnormal)
+		api.GET("/alarm-limits", controllers.GetAlarmLimits)
+		api.POST("/alarm-limits", controllers.CreateAlarmLimit)
+		api.PUT("/alarm-limits/:id", controllers.UpdateAlarmLimit)
+		api.DELETE("/alarm-limits/:id", controllers.DeleteAlarmLimit)
+		api.GET("/divert-events", controllers.GetDivertEvents)
+		api.GET("/htst/compliance", controllers.GetHTSTCompliance)
+		api.GET("/htst/config", controllers.GetHTSTConfig)
+		api.PUT("/htst/config", controllers.UpdateHTSTConfig)
+		api.POST("/htst/signoff", controllers.SignOffHTSTDay)
+		api.GET("/htst/signoffs", controllers.GetHTSTSignoffs)
+		api.GET("/series", controllers.GetPasteurSeries)
+		api.GET("/tanks", controllers.GetTankAnalytics)
+		api.GET("/tanks/events", controllers.GetTankEvents)
 		api.GET("/average/flowrate", controllers.GetAverageFlowrate)
 		api.GET("/average/suhu-heating", controllers.GetAverageSuhuHeating)
 		api.GET("/average/suhu-holding", controllers.GetAverageSuhuHolding)
@@ -8,9 +8,44 @@ import (
 func RegisterRetailRoutes(r *gin.Engine) {
 	api := r.Group("/api/retail")
 	{
+		api.GET("/lines", controllers.GetRetailLines)
+		api.POST("/lines", controllers.CreateRetailLine)
+		api.PUT("/lines/:id", controllers.UpdateRetailLine)
+		api.GET("/overview", controllers.RetailOverview)
+		api.GET("/compare", controllers.RetailCompare)
+		api.GET("/timeline", controllers.RetailTimelineAll)
+		api.GET("/downtime/pareto", controllers.GetDowntimePareto)
+		api.GET("/reliability/ranking", controllers.RetailReliabilityRanking)
+
+		api.GET("/reasons", controllers.GetDowntimeReasons)
+		api.POST("/reasons", controllers.CreateDowntimeReason)
+		api.PUT("/reasons/:id", controllers.UpdateDowntimeReason)
+
+		api.GET("/targets", controllers.GetProductionTargets)
+		api.POST("/targets", controllers.CreateProductionTarget)
+		api.PUT("/targets/:id", controllers.UpdateProductionTarget)
+		api.DELETE("/targets/:id", controllers.DeleteProductionTarget)
+
 		api.GET("/:line/durasi/start", controllers.UptimeStartMesinRealtime)
 		api.GET("/:line/durasi/stop", controllers.DowntimeStopMesinRealtime)
 		api.GET("/:line/performance-output", controllers.PerformanceOutput)
 		api.GET("/:line/output-gagal-filling", controllers.OutputGagalFilling)
+		api.GET("/:line/anomalies", controllers.RetailAnomalies)
+		api.GET("/:line/oee", controllers.RetailOEE)
+		api.GET("/:line/kpi", controllers.RetailKPI)
+		api.GET("/:line/output/hourly", controllers.RetailHourlyOutput)
+		api.GET("/:line/reliability", controllers.RetailReliability)
+		api.GET("/:line/speed-loss", controllers.RetailSpeedLoss)
+		api.GET("/:line/timeline", controllers.RetailTimeline)
+		api.GET("/:line/stops", controllers.RetailStopEvents)
+		api.GET("/:line/stops/classification", controllers.RetailStopClassification)
+		api.GET("/:line/stops/reasons", controllers.GetStopReasons)
+		api.POST("/:line/stops/reasons", controllers.AssignStopReason)
+		api.PUT("/:line/stops/reasons/:id", controllers.UpdateStopReason)
+		api.GET("/:line/runs", controllers.GetProductionRuns)
+		api.POST("/:line/runs", controllers.CreateProductionRun)
+		api.POST("/:line/runs/infer", controllers.InferProductionRuns)
+		api.PUT("/:line/runs/:id", controllers.UpdateProductionRun)
+		api.DELETE("/:line/runs/:id", controllers.DeleteProductionRun)
 	}
 }
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"backend-golang/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterShiftRoutes(r *gin.Engine) {
+	api := r.Group("/api/shifts")
+	{
+		api.GET("/calendar", controllers.GetShiftCalendar)
+
+		api.GET("/patterns", controllers.GetShiftPatterns)
+		api.POST("/patterns", controllers.CreateShiftPattern)
+		api.PUT("/patterns/:id", controllers.UpdateShiftPattern)
+		api.DELETE("/patterns/:id", controllers.DeleteShiftPattern)
+
+		api.GET("/holidays", controllers.GetShiftHolidays)
+		api.POST("/holidays", controllers.CreateShiftHoliday)
+		api.DELETE("/holidays/:id", controllers.DeleteShiftHoliday)
+	}
+}
@@ -0,0 +1,316 @@
+// Package shiftcal adalah satu-satunya sumber definisi shift untuk semua controller.
+// Pola shift disimpan di tabel shift_patterns dan shift_holidays, dengan fallback
+// ke pola bawaan pabrik jika belum ada konfigurasi.
+package shiftcal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend-golang/config"
+	"backend-golang/models"
+)
+
+// Jenis hari
+const (
+	Weekday  = "weekday"
+	Saturday = "saturday"
+	Sunday   = "sunday"
+	Holiday  = "holiday"
+)
+
+// Shift hasil resolve kalender untuk satu tanggal produksi
+type Shift struct {
+	No             int       `json:"shift"`
+	Date           string    `json:"date"` // tanggal produksi (shift 3 berakhir besok pagi)
+	DayType        string    `json:"day_type"`
+	Start          time.Time `json:"start_time"`
+	End            time.Time `json:"end_time"`
+	PlannedMinutes int64     `json:"planned_minutes"`
+}
+
+// Pola bawaan pabrik, dipakai jika tabel shift_patterns belum berisi pola untuk jenis hari tersebut
+var defaultPatterns = map[string][]models.ShiftPattern{
+	Weekday: {
+		{ShiftNo: 1, StartTime: "06:00:00", EndTime: "14:00:00", PlannedMinutes: 420},
+		{ShiftNo: 2, StartTime: "14:00:01", EndTime: "22:00:00", PlannedMinutes: 420},
+		{ShiftNo: 3, StartTime: "22:00:01", EndTime: "05:59:59", PlannedMinutes: 420},
+	},
+	Saturday: {
+		{ShiftNo: 1, StartTime: "06:00:00", EndTime: "11:00:00", PlannedMinutes: 300},
+		{ShiftNo: 2, StartTime: "11:00:01", EndTime: "16:00:00", PlannedMinutes: 300},
+		{ShiftNo: 3, StartTime: "16:00:01", EndTime: "21:00:00", PlannedMinutes: 300},
+	},
+	// Minggu mengikuti hari biasa, hari libur tidak ada shift
+	Sunday: {
+		{ShiftNo: 1, StartTime: "06:00:00", EndTime: "14:00:00", PlannedMinutes: 420},
+		{ShiftNo: 2, StartTime: "14:00:01", EndTime: "22:00:00", PlannedMinutes: 420},
+		{ShiftNo: 3, StartTime: "22:00:01", EndTime: "05:59:59", PlannedMinutes: 420},
+	},
+	Holiday: {},
+}
+
+// Pola 24 jam separator, sama dengan pembagian shift lama di controller separator
+var separatorPattern = []models.ShiftPattern{
+	{ShiftNo: 1, StartTime: "06:00:00", EndTime: "14:00:00"},
+	{ShiftNo: 2, StartTime: "14:00:01", EndTime: "22:00:00"},
+	{ShiftNo: 3, StartTime: "22:00:01", EndTime: "05:59:59"},
+}
+
+// Pola bawaan khusus line. Separator berjalan 24 jam setiap hari, termasuk Sabtu dan libur.
+var defaultLinePatterns = map[string]map[string][]models.ShiftPattern{
+	"separator": {
+		Weekday:  separatorPattern,
+		Saturday: separatorPattern,
+		Sunday:   separatorPattern,
+		Holiday:  separatorPattern,
+	},
+}
+
+// ValidDayType cek apakah jenis hari dikenal
+func ValidDayType(dayType string) bool {
+	_, ok := defaultPatterns[dayType]
+	return ok
+}
+
+// ValidatePattern cek isi pola shift sebelum disimpan
+func ValidatePattern(p models.ShiftPattern) error {
+	if !ValidDayType(p.DayType) {
+		return fmt.Errorf("day_type harus salah satu dari weekday, saturday, sunday, holiday")
+	}
+	if p.ShiftNo < 1 {
+		return errors.New("shift_no harus lebih dari 0")
+	}
+	if _, err := time.Parse("15:04:05", p.StartTime); err != nil {
+		return errors.New("start_time harus berformat HH:MM:SS")
+	}
+	if _, err := time.Parse("15:04:05", p.EndTime); err != nil {
+		return errors.New("end_time harus berformat HH:MM:SS")
+	}
+	if p.PlannedMinutes < 0 {
+		return errors.New("planned_minutes tidak boleh negatif")
+	}
+	return nil
+}
+
+// DayTypeOf tentukan jenis hari untuk line pada tanggal tertentu
+func DayTypeOf(line string, date time.Time) (string, error) {
+	var holidays int64
+	err := config.DB.Model(&models.ShiftHoliday{}).
+		Where("date = ? AND (line = '' OR line = ?)", date.Format("2006-01-02"), strings.ToLower(line)).
+		Count(&holidays).Error
+	if err != nil {
+		return "", err
+	}
+	return dayTypeFor(date, holidays > 0), nil
+}
+
+// Jenis hari dari tanggal, hari libur mengalahkan hari dalam minggu
+func dayTypeFor(date time.Time, holiday bool) string {
+	switch {
+	case holiday:
+		return Holiday
+	case date.Weekday() == time.Sunday:
+		return Sunday
+	case date.Weekday() == time.Saturday:
+		return Saturday
+	default:
+		return Weekday
+	}
+}
+
+// Ambil pola shift dari database lalu pilih sesuai urutan prioritas resolvePatterns
+func patternsFor(line, dayType string) ([]models.ShiftPattern, error) {
+	var patterns []models.ShiftPattern
+	err := config.DB.
+		Where("day_type = ? AND (line = '' OR line = ?)", dayType, strings.ToLower(line)).
+		Order("shift_no ASC").
+		Find(&patterns).Error
+	if err != nil {
+		return nil, err
+	}
+	return resolvePatterns(line, dayType, patterns), nil
+}
+
+// Prioritas pola: pola khusus line di database > pola bawaan khusus line >
+// pola global di database > pola bawaan pabrik
+func resolvePatterns(line, dayType string, patterns []models.ShiftPattern) []models.ShiftPattern {
+	var lineSpecific, global []models.ShiftPattern
+	for _, p := range patterns {
+		if p.Line == "" {
+			global = append(global, p)
+		} else {
+			lineSpecific = append(lineSpecific, p)
+		}
+	}
+
+	if len(lineSpecific) > 0 {
+		return lineSpecific
+	}
+	if builtin, ok := defaultLinePatterns[strings.ToLower(line)]; ok {
+		return builtin[dayType]
+	}
+	if len(global) > 0 {
+		return global
+	}
+	return defaultPatterns[dayType]
+}
+
+// Ubah pola jam ke rentang waktu pada tanggal produksi
+func buildShift(p models.ShiftPattern, date time.Time, dayType string) Shift {
+	loc := date.Location()
+	startClock, _ := time.Parse("15:04:05", p.StartTime)
+	endClock, _ := time.Parse("15:04:05", p.EndTime)
+
+	start := time.Date(date.Year(), date.Month(), date.Day(), startClock.Hour(), startClock.Minute(), startClock.Second(), 0, loc)
+	end := time.Date(date.Year(), date.Month(), date.Day(), endClock.Hour(), endClock.Minute(), endClock.Second(), 0, loc)
+	if !end.After(start) {
+		// Shift melewati tengah malam
+		end = end.AddDate(0, 0, 1)
+	}
+
+	planned := int64(p.PlannedMinutes)
+	if planned == 0 {
+		planned = int64(end.Sub(start).Minutes())
+	}
+
+	return Shift{
+		No:             p.ShiftNo,
+		Date:           date.Format("2006-01-02"),
+		DayType:        dayType,
+		Start:          start,
+		End:            end,
+		PlannedMinutes: planned,
+	}
+}
+
+// ForDate ambil semua shift line pada tanggal produksi, urut berdasarkan nomor shift
+func ForDate(line string, date time.Time) ([]Shift, error) {
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+
+	dayType, err := DayTypeOf(line, date)
+	if err != nil {
+		return nil, err
+	}
+	patterns, err := patternsFor(line, dayType)
+	if err != nil {
+		return nil, err
+	}
+
+	shifts := make([]Shift, 0, len(patterns))
+	for _, p := range patterns {
+		shifts = append(shifts, buildShift(p, date, dayType))
+	}
+	return shifts, nil
+}
+
+// Find ambil satu shift berdasarkan nomor, ok = false jika shift tidak ada di tanggal tersebut
+func Find(line string, date time.Time, no int) (Shift, bool, error) {
+	shifts, err := ForDate(line, date)
+	if err != nil {
+		return Shift{}, false, err
+	}
+	for _, s := range shifts {
+		if s.No == no {
+			return s, true, nil
+		}
+	}
+	return Shift{}, false, nil
+}
+
+// At cari shift yang sedang berjalan pada waktu t (termasuk shift malam dari hari sebelumnya)
+func At(line string, t time.Time) (Shift, bool, error) {
+	for _, date := range []time.Time{t.AddDate(0, 0, -1), t} {
+		shifts, err := ForDate(line, date)
+		if err != nil {
+			return Shift{}, false, err
+		}
+		for _, s := range shifts {
+			if !t.Before(s.Start) && !t.After(s.End) {
+				return s, true, nil
+			}
+		}
+	}
+	return Shift{}, false, nil
+}
+
+// Latest cari shift yang sedang berjalan pada waktu t, atau shift terakhir yang sudah
+// selesai dalam seminggu terakhir jika t berada di luar jam shift (jeda antar shift,
+// malam Sabtu, hari libur). ok = false jika tidak ada shift sama sekali.
+func Latest(line string, t time.Time) (Shift, bool, error) {
+	if shift, ok, err := At(line, t); err != nil || ok {
+		return shift, ok, err
+	}
+	for back := 0; back <= 7; back++ {
+		shifts, err := ForDate(line, t.AddDate(0, 0, -back))
+		if err != nil {
+			return Shift{}, false, err
+		}
+		if shift, ok := lastEnded(shifts, t); ok {
+			return shift, true, nil
+		}
+	}
+	return Shift{}, false, nil
+}
+
+// Shift dengan akhir paling akhir yang sudah selesai sebelum t
+func lastEnded(shifts []Shift, t time.Time) (Shift, bool) {
+	var last Shift
+	found := false
+	for _, s := range shifts {
+		if s.End.Before(t) && (!found || s.End.After(last.End)) {
+			last, found = s, true
+		}
+	}
+	return last, found
+}
+
+// DayRange rentang operasional satu tanggal produksi (awal shift pertama - akhir shift terakhir)
+func DayRange(shifts []Shift, date time.Time) (time.Time, time.Time) {
+	if len(shifts) == 0 {
+		start := time.Date(date.Year(), date.Month(), date.Day(), 6, 0, 0, 0, date.Location())
+		return start, start.Add(24*time.Hour - time.Second)
+	}
+	start, end := shifts[0].Start, shifts[0].End
+	for _, s := range shifts[1:] {
+		if s.Start.Before(start) {
+			start = s.Start
+		}
+		if s.End.After(end) {
+			end = s.End
+		}
+	}
+	return start, end
+}
+
+// ActualMinutes menit shift yang sudah berjalan sampai now, dibatasi planned minutes
+func (s Shift) ActualMinutes(now time.Time) int64 {
+	var actualMinutes int64
+	if now.Before(s.Start) {
+		actualMinutes = 0
+	} else if now.After(s.End) {
+		actualMinutes = int64(s.End.Sub(s.Start).Minutes())
+	} else {
+		actualMinutes = int64(now.Sub(s.Start).Minutes())
+	}
+
+	if actualMinutes > s.PlannedMinutes {
+		actualMinutes = s.PlannedMinutes
+	}
+	return actualMinutes
+}
+
+// ForRange ambil semua shift dari tanggal from sampai to (inklusif)
+func ForRange(line string, from, to time.Time) ([]Shift, error) {
+	var shifts []Shift
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		dayShifts, err := ForDate(line, date)
+		if err != nil {
+			return nil, err
+		}
+		shifts = append(shifts, dayShifts...)
+	}
+	return shifts, nil
+}
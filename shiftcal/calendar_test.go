@@ -0,0 +1,128 @@
+package shiftcal
+
+import (
+	"testing"
+	"time"
+
+	"backend-golang/models"
+)
+
+var wib = time.FixedZone("WIB", 7*60*60)
+
+func TestDayTypeFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		date    time.Time
+		holiday bool
+		want    string
+	}{
+		{name: "senin", date: time.Date(2026, 1, 5, 0, 0, 0, 0, wib), want: Weekday},
+		{name: "sabtu", date: time.Date(2026, 1, 3, 0, 0, 0, 0, wib), want: Saturday},
+		{name: "minggu", date: time.Date(2026, 1, 4, 0, 0, 0, 0, wib), want: Sunday},
+		{name: "libur di hari sabtu", date: time.Date(2026, 1, 3, 0, 0, 0, 0, wib), holiday: true, want: Holiday},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dayTypeFor(tt.date, tt.holiday); got != tt.want {
+				t.Errorf("dayTypeFor = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolvePatterns(t *testing.T) {
+	global := models.ShiftPattern{DayType: Weekday, ShiftNo: 1, StartTime: "07:00:00", EndTime: "15:00:00"}
+	lineD5 := models.ShiftPattern{Line: "d5", DayType: Weekday, ShiftNo: 1, StartTime: "08:00:00", EndTime: "16:00:00"}
+
+	tests := []struct {
+		name      string
+		line      string
+		dayType   string
+		patterns  []models.ShiftPattern
+		wantStart string
+		wantCount int
+	}{
+		{name: "pola line mengalahkan pola global", line: "d5", dayType: Weekday, patterns: []models.ShiftPattern{global, lineD5}, wantStart: "08:00:00", wantCount: 1},
+		{name: "pola global untuk line lain", line: "d1", dayType: Weekday, patterns: []models.ShiftPattern{global}, wantStart: "07:00:00", wantCount: 1},
+		{name: "pola bawaan tanpa konfigurasi", line: "d1", dayType: Weekday, wantStart: "06:00:00", wantCount: 3},
+		{name: "pola bawaan sabtu", line: "d1", dayType: Saturday, wantStart: "06:00:00", wantCount: 3},
+		{name: "hari libur tanpa shift", line: "d1", dayType: Holiday, wantCount: 0},
+		{name: "separator tetap 24 jam saat libur", line: "separator", dayType: Holiday, wantStart: "06:00:00", wantCount: 3},
+		{name: "pola bawaan separator mengalahkan pola global", line: "separator", dayType: Weekday, patterns: []models.ShiftPattern{global}, wantStart: "06:00:00", wantCount: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolvePatterns(tt.line, tt.dayType, tt.patterns)
+			if len(got) != tt.wantCount {
+				t.Fatalf("jumlah pola = %d, want %d", len(got), tt.wantCount)
+			}
+			if len(got) > 0 && got[0].StartTime != tt.wantStart {
+				t.Errorf("start shift pertama = %s, want %s", got[0].StartTime, tt.wantStart)
+			}
+		})
+	}
+}
+
+func TestBuildShift(t *testing.T) {
+	date := time.Date(2026, 1, 3, 0, 0, 0, 0, wib) // Sabtu
+	at := func(day, h, m, s int) time.Time { return time.Date(2026, 1, day, h, m, s, 0, wib) }
+
+	tests := []struct {
+		name    string
+		pattern models.ShiftPattern
+		start   time.Time
+		end     time.Time
+		planned int64
+	}{
+		{name: "shift 2 sabtu", pattern: defaultPatterns[Saturday][1], start: at(3, 11, 0, 1), end: at(3, 16, 0, 0), planned: 300},
+		{name: "shift 3 sabtu selesai 21:00", pattern: defaultPatterns[Saturday][2], start: at(3, 16, 0, 1), end: at(3, 21, 0, 0), planned: 300},
+		{name: "shift 3 melewati tengah malam", pattern: defaultPatterns[Weekday][2], start: at(3, 22, 0, 1), end: at(4, 5, 59, 59), planned: 420},
+		{name: "planned kosong dihitung dari rentang", pattern: separatorPattern[0], start: at(3, 6, 0, 0), end: at(3, 14, 0, 0), planned: 480},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildShift(tt.pattern, date, Saturday)
+			if !got.Start.Equal(tt.start) || !got.End.Equal(tt.end) {
+				t.Errorf("rentang = %v - %v, want %v - %v", got.Start, got.End, tt.start, tt.end)
+			}
+			if got.PlannedMinutes != tt.planned {
+				t.Errorf("planned = %d, want %d", got.PlannedMinutes, tt.planned)
+			}
+			if got.Date != "2026-01-03" {
+				t.Errorf("tanggal produksi = %s, want 2026-01-03", got.Date)
+			}
+		})
+	}
+}
+
+func TestLastEnded(t *testing.T) {
+	date := time.Date(2026, 1, 3, 0, 0, 0, 0, wib) // Sabtu
+	var saturday []Shift
+	for _, p := range defaultPatterns[Saturday] {
+		saturday = append(saturday, buildShift(p, date, Saturday))
+	}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want int // 0 = tidak ada
+	}{
+		{name: "malam sabtu setelah shift 3", t: time.Date(2026, 1, 3, 23, 0, 0, 0, wib), want: 3},
+		{name: "jeda 1 detik antar shift", t: time.Date(2026, 1, 3, 11, 0, 0, 500, wib), want: 1},
+		{name: "sebelum shift pertama", t: time.Date(2026, 1, 3, 5, 0, 0, 0, wib), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := lastEnded(saturday, tt.t)
+			if tt.want == 0 {
+				if ok {
+					t.Errorf("dapat shift %d, want tidak ada", got.No)
+				}
+				return
+			}
+			if !ok || got.No != tt.want {
+				t.Errorf("shift = %d (ok %v), want %d", got.No, ok, tt.want)
+			}
+		})
+	}
+}